@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
@@ -12,18 +13,29 @@ import (
 
 	"github.com/sir_venger/s3_lite/internal/app/resthttp"
 	"github.com/sir_venger/s3_lite/internal/config"
+	"github.com/sir_venger/s3_lite/internal/repo/meta"
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "apply meta schema migrations and exit")
+	migrateDownTo := flag.Int64("migrate-down-to", -1, "roll meta schema back to the given version and exit")
+	flag.Parse()
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	handler, _, err := resthttp.NewServer(cfg)
+	if *migrateOnly || *migrateDownTo >= 0 {
+		runMigrations(cfg.MetaDSN, *migrateDownTo)
+		return
+	}
+
+	handler, srv, err := resthttp.NewServer(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer srv.Close()
 
 	server := &http.Server{
 		Addr:    cfg.ListenAddr,
@@ -54,3 +66,24 @@ func main() {
 		log.Printf("STORAGE final shutdown error: %v", err)
 	}
 }
+
+// runMigrations накатывает миграции (или откатывает до downTo, если он неотрицателен) и сообщает итоговую версию.
+func runMigrations(dsn string, downTo int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var (
+		version int64
+		err     error
+	)
+	if downTo >= 0 {
+		version, err = meta.MigrateTo(ctx, dsn, downTo)
+	} else {
+		version, err = meta.MigrateUp(ctx, dsn)
+	}
+	if err != nil {
+		log.Fatalf("migrations failed: %v", err)
+	}
+
+	log.Printf("meta schema is at version %d", version)
+}
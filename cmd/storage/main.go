@@ -19,6 +19,7 @@ const (
 	dataDirEnv           = "DATA_DIR"
 	gcTTLHoursEnv        = "GC_TTL_HOURS"
 	gcIntervalMinEnv     = "GC_INTERVAL_MIN"
+	flockEnv             = "STORAGE_FLOCK"
 	defaultDataDir       = "/data"
 	defaultGCTTLHours    = 24
 	defaultGCIntervalMin = 30
@@ -36,6 +37,13 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Межпроцессные блокировки нужны, если с DATA_DIR работает ещё один процесс.
+	if flock, _ := strconv.ParseBool(os.Getenv(flockEnv)); flock {
+		if err := storagehttp.UseFlock(dataDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	h := storagehttp.New(dataDir)
 
 	// Настраиваем фоновый GC по удалению незавершённых загрузок.
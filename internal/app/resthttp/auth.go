@@ -0,0 +1,75 @@
+package resthttp
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+const (
+	// headerAPIKey — альтернатива "Authorization: Bearer <ключ>".
+	headerAPIKey = "X-API-Key"
+	// bootstrapKeyID — идентификатор ключа из конфига (admin_api_key).
+	bootstrapKeyID = "config"
+)
+
+// authenticate пропускает запрос, если ключ из заголовка даёт право на операцию (см. requiredPermission).
+// OPTIONS и запросы по проверенному presigned URL ключа не требуют.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || isPresigned(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := s.apiKey(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="storage_lite"`)
+			httperrors.Write(w, err)
+			return
+		}
+		if !key.Allows(requiredPermission(r)) {
+			httperrors.Write(w, models.ErrForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiKey находит ключ запроса: ключ из конфига (admin_api_key) или выпущенный через /admin/keys.
+func (s *Server) apiKey(r *http.Request) (models.APIKey, error) {
+	token := r.Header.Get(headerAPIKey)
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = strings.TrimSpace(v)
+	}
+	if token == "" {
+		return models.APIKey{}, models.ErrUnauthorized
+	}
+
+	if admin := s.Cfg.AdminAPIKey; admin != "" && subtle.ConstantTimeCompare([]byte(token), []byte(admin)) == 1 {
+		return models.APIKey{ID: bootstrapKeyID, Permissions: []models.Permission{models.PermAdmin}}, nil
+	}
+
+	return s.FilesService.AuthenticateAPIKey(r.Context(), token)
+}
+
+// requiredPermission — право, нужное для запроса: /admin/* — admin, чтение — read,
+// DELETE — delete, остальные методы — write. Ссылку на скачивание выдаёт право read.
+func requiredPermission(r *http.Request) models.Permission {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/admin/"):
+		return models.PermAdmin
+	case strings.HasSuffix(r.URL.Path, "/presign") && r.URL.Path != "/files/presign":
+		return models.PermRead
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return models.PermRead
+	case r.Method == http.MethodDelete:
+		return models.PermDelete
+	default:
+		return models.PermWrite
+	}
+}
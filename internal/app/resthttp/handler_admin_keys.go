@@ -0,0 +1,92 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+type createKeyRequest struct {
+	Name        string              `json:"name"`
+	Permissions []models.Permission `json:"permissions"`
+}
+
+type apiKeyResp struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name,omitempty"`
+	Permissions []models.Permission `json:"permissions"`
+	CreatedAt   time.Time           `json:"created_at"`
+	// Key — токен для заголовков; отдаётся только при создании ключа.
+	Key string `json:"key,omitempty"`
+}
+
+type listKeysResp struct {
+	Keys []apiKeyResp `json:"keys"`
+}
+
+// mountAdminKeys регистрирует управление API-ключами (/admin/keys).
+func (s *Server) mountAdminKeys(r chi.Router) {
+	r.Post("/", s.createKey)
+	r.Get("/", s.listKeys)
+	r.Delete("/{id}", s.deleteKey)
+}
+
+// createKey выпускает ключ. Секрет возвращается один раз: в метаданных хранится только его хеш.
+func (s *Server) createKey(w http.ResponseWriter, r *http.Request) {
+	var payload createKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, token, err := s.FilesService.CreateAPIKey(r.Context(), payload.Name, payload.Permissions)
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	resp := toAPIKeyResp(key)
+	resp.Key = token
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) listKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.FilesService.ListAPIKeys(r.Context())
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	resp := listKeysResp{Keys: make([]apiKeyResp, 0, len(keys))}
+	for _, k := range keys {
+		resp.Keys = append(resp.Keys, toAPIKeyResp(k))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// deleteKey отзывает ключ; следующий запрос с ним получит 401.
+func (s *Server) deleteKey(w http.ResponseWriter, r *http.Request) {
+	if err := s.FilesService.DeleteAPIKey(r.Context(), chi.URLParam(r, "id")); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toAPIKeyResp(k models.APIKey) apiKeyResp {
+	return apiKeyResp{
+		ID:          k.ID,
+		Name:        k.Name,
+		Permissions: k.Permissions,
+		CreatedAt:   k.CreatedAt,
+	}
+}
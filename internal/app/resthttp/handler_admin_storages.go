@@ -0,0 +1,113 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
+	adapters "github.com/sir_venger/s3_lite/internal/usecase/filesvc/adapters/storage"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+type putStoragesRequest struct {
+	Storages []string `json:"storages"`
+	// State — active (по умолчанию) или draining.
+	State models.NodeState `json:"state"`
+}
+
+// storageResp — узел кластера: состояние в составе (membership) и последний результат health из кеша монитора.
+type storageResp struct {
+	adapters.NodeStatus
+	Membership models.NodeState `json:"membership"`
+	AddedAt    time.Time        `json:"added_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+type storagesResp struct {
+	Storages []storageResp `json:"storages"`
+}
+
+// mountAdminStorages регистрирует управление составом кластера (/admin/storages).
+func (s *Server) mountAdminStorages(r chi.Router) {
+	r.Get("/", s.listStorages)
+	r.Post("/", s.putStorages)
+	r.Delete("/", s.removeStorage)
+}
+
+// listStorages отдаёт состав кластера с состоянием узлов из кеша монитора.
+func (s *Server) listStorages(w http.ResponseWriter, r *http.Request) {
+	nodes, err := s.FilesService.ListStorages(r.Context())
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	resp := storagesResp{Storages: make([]storageResp, 0, len(nodes))}
+	for _, node := range nodes {
+		resp.Storages = append(resp.Storages, storageResp{
+			NodeStatus: s.health.Status(node.URL),
+			Membership: node.State,
+			AddedAt:    node.CreatedAt,
+			UpdatedAt:  node.UpdatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// putStorages добавляет узлы в кластер или меняет их состояние (например, переводит в draining).
+func (s *Server) putStorages(w http.ResponseWriter, r *http.Request) {
+	var payload putStoragesRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(payload.Storages) == 0 {
+		http.Error(w, "storages list is empty", http.StatusBadRequest)
+		return
+	}
+	if payload.State == "" {
+		payload.State = models.NodeActive
+	}
+
+	if _, err := s.FilesService.PutStorages(r.Context(), payload.State, payload.Storages...); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+	s.trackStorages(r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeStorage убирает узел из кластера: DELETE /admin/storages?url=<адрес>.
+func (s *Server) removeStorage(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "url query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.FilesService.RemoveStorage(r.Context(), url); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+	s.trackStorages(r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trackStorages сразу передаёт монитору изменённый состав, не дожидаясь фоновой синхронизации.
+func (s *Server) trackStorages(r *http.Request) {
+	if nodes, err := s.FilesService.ListStorages(r.Context()); err == nil {
+		s.health.Track(nodeURLs(nodes))
+	}
+}
+
+func nodeURLs(nodes []models.StorageNode) []string {
+	out := make([]string, len(nodes))
+	for i, node := range nodes {
+		out[i] = node.URL
+	}
+	return out
+}
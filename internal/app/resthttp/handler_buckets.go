@@ -0,0 +1,142 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+type createBucketRequest struct {
+	Name string `json:"name"`
+}
+
+type listBucketsResp struct {
+	Buckets []models.Bucket `json:"buckets"`
+}
+
+// mountBuckets регистрирует бакеты и объекты, адресуемые ключом: /buckets/{bucket}/objects/{key}.
+func (s *Server) mountBuckets(r chi.Router) {
+	r.Post("/", s.createBucket)
+	r.Get("/", s.listBuckets)
+	r.Delete("/{bucket}", s.deleteBucket)
+	r.Get("/{bucket}/objects", s.listObjects)
+	r.Put("/{bucket}/objects/*", s.putObject)
+	r.Get("/{bucket}/objects/*", s.getObject)
+	r.Head("/{bucket}/objects/*", s.headObject)
+	r.Delete("/{bucket}/objects/*", s.deleteObject)
+}
+
+func (s *Server) createBucket(w http.ResponseWriter, r *http.Request) {
+	var payload createBucketRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bucket, err := s.FilesService.CreateBucket(r.Context(), payload.Name)
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/buckets/"+bucket.Name)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(bucket)
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	buckets, err := s.FilesService.ListBuckets(r.Context())
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+	if buckets == nil {
+		buckets = []models.Bucket{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listBucketsResp{Buckets: buckets})
+}
+
+// deleteBucket удаляет пустой бакет; 409, если в нём остались объекты.
+func (s *Server) deleteBucket(w http.ResponseWriter, r *http.Request) {
+	if err := s.FilesService.DeleteBucket(r.Context(), chi.URLParam(r, "bucket")); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listObjects обрабатывает GET /buckets/{bucket}/objects с параметрами GET /files; prefix относится к ключу.
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Bucket = chi.URLParam(r, "bucket")
+	if _, err = s.FilesService.GetBucket(r.Context(), filter.Bucket); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	s.writeFileList(w, r, filter)
+}
+
+// putObject записывает тело под ключом; существующий объект с этим ключом заменяется.
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request) {
+	res, err := s.FilesService.PutObject(r.Context(), chi.URLParam(r, "bucket"), objectKeyParam(r), r.Body, r.ContentLength)
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(postFilesResp{
+		FileID: res.FileID,
+		Size:   res.Size,
+		Parts:  res.Parts,
+	})
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request) {
+	file, err := s.FilesService.StatObject(r.Context(), chi.URLParam(r, "bucket"), objectKeyParam(r))
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	s.serveFile(w, r, file)
+}
+
+func (s *Server) headObject(w http.ResponseWriter, r *http.Request) {
+	file, err := s.FilesService.StatObject(r.Context(), chi.URLParam(r, "bucket"), objectKeyParam(r))
+	if err != nil {
+		w.WriteHeader(httperrors.Status(err))
+		return
+	}
+
+	writeFileHead(w, file)
+}
+
+func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request) {
+	if err := s.FilesService.DeleteObject(r.Context(), chi.URLParam(r, "bucket"), objectKeyParam(r)); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// objectKeyParam возвращает ключ объекта из пути. Берётся декодированный r.URL.Path, а не параметр chi:
+// при экранированных символах chi сопоставляет маршрут по RawPath.
+func objectKeyParam(r *http.Request) string {
+	_, key, _ := strings.Cut(r.URL.Path, "/buckets/"+chi.URLParam(r, "bucket")+"/objects/")
+	return key
+}
@@ -0,0 +1,19 @@
+package resthttp
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+func (s *Server) deleteFile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := s.FilesService.Delete(r.Context(), id); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,85 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+type fileMetaResp struct {
+	FileID     string        `json:"file_id"`
+	Name       string        `json:"file_name,omitempty"`
+	Bucket     string        `json:"bucket,omitempty"`
+	Key        string        `json:"key,omitempty"`
+	Size       int64         `json:"size"`
+	TotalParts int           `json:"total_parts"`
+	ETag       string        `json:"etag"`
+	Parts      []models.Part `json:"parts"`
+
+	Erasure *models.ErasureLayout `json:"erasure,omitempty"`
+}
+
+// headFile отдаёт заголовки файла без тела.
+func (s *Server) headFile(w http.ResponseWriter, r *http.Request) {
+	file, err := s.FilesService.Stat(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(httperrors.Status(err))
+		return
+	}
+
+	writeFileHead(w, file)
+}
+
+func writeFileHead(w http.ResponseWriter, file models.File) {
+	setFileHeaders(w.Header(), file)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// getFileMeta возвращает описание файла и раскладку частей по стораджам.
+func (s *Server) getFileMeta(w http.ResponseWriter, r *http.Request) {
+	file, err := s.FilesService.Stat(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	parts := make([]models.Part, 0, len(file.Parts))
+	for _, p := range file.Parts {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Index < parts[j].Index
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(fileMetaResp{
+		FileID:     file.ID,
+		Name:       file.Name,
+		Bucket:     file.Bucket,
+		Key:        file.Key,
+		Size:       file.Size,
+		TotalParts: file.TotalParts,
+		ETag:       file.ETag(),
+		Parts:      parts,
+		Erasure:    file.Erasure,
+	})
+}
+
+// setFileHeaders выставляет общие для GET и HEAD заголовки представления файла.
+func setFileHeaders(h http.Header, file models.File) {
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("ETag", file.ETag())
+	h.Set("Content-Type", "application/octet-stream")
+	if file.Name != "" {
+		if v := mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}); v != "" {
+			h.Set("Content-Disposition", v)
+		}
+	}
+}
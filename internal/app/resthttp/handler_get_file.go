@@ -1,17 +1,92 @@
 package resthttp
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
 	"github.com/sir_venger/s3_lite/pkg/httperrors"
 )
 
+// headerContentSha256 — трейлер с sha256 всего отданного файла.
+const headerContentSha256 = "X-Checksum-Sha256"
+
 func (s *Server) getFile(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	if err := s.FilesService.Stream(r.Context(), id, w); err != nil {
+	file, err := s.FilesService.Stat(r.Context(), id)
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	s.serveFile(w, r, file)
+}
+
+// serveFile отдаёт содержимое файла целиком или диапазоном из заголовка Range.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, file models.File) {
+	rng, partial, err := requestedRange(r, file)
+	if err != nil {
+		w.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(file.Size, 10))
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	h := w.Header()
+	setFileHeaders(h, file)
+	h.Set("Content-Length", strconv.FormatInt(rng.length, 10))
+
+	out := &deferredWriter{w: w, status: http.StatusOK}
+	if partial {
+		h.Set("Content-Range", rng.contentRange(file.Size))
+		out.status = http.StatusPartialContent
+	}
+
+	// Трейлер с дайджестом всего файла возможен только в chunked-ответе,
+	// поэтому отдаём его лишь клиентам, явно согласившимся на трейлеры (TE: trailers).
+	var (
+		body   io.Writer = out
+		digest hash.Hash
+	)
+	if !partial && acceptsTrailers(r) {
+		digest = sha256.New()
+		body = io.MultiWriter(out, digest)
+		h.Del("Content-Length")
+		h.Set("Trailer", headerContentSha256)
+	}
+
+	if err = s.FilesService.StreamRange(r.Context(), file, rng.start, rng.length, body); err != nil {
+		if out.started {
+			// Заголовки и часть тела уже ушли клиенту: обрываем соединение,
+			// чтобы клиент увидел недокачку, а не испорченные данные.
+			panic(http.ErrAbortHandler)
+		}
+		for _, k := range []string{"Accept-Ranges", "ETag", "Content-Type", "Content-Disposition", "Content-Length", "Content-Range", "Trailer"} {
+			h.Del(k)
+		}
 		httperrors.Write(w, err)
 		return
 	}
+	out.start()
+
+	if digest != nil {
+		h.Set(headerContentSha256, hex.EncodeToString(digest.Sum(nil)))
+	}
+}
+
+func acceptsTrailers(r *http.Request) bool {
+	for _, v := range r.Header.Values("TE") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "trailers") {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -0,0 +1,99 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+type listFilesItem struct {
+	FileID     string    `json:"file_id"`
+	Name       string    `json:"file_name,omitempty"`
+	Bucket     string    `json:"bucket,omitempty"`
+	Key        string    `json:"key,omitempty"`
+	Size       int64     `json:"size"`
+	TotalParts int       `json:"total_parts"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type listFilesResp struct {
+	Files      []listFilesItem `json:"files"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// listFiles обрабатывает GET /files?prefix=&limit=&cursor=&sort=created|size&order=asc|desc.
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.writeFileList(w, r, filter)
+}
+
+// writeFileList отвечает страницей списка файлов в JSON.
+func (s *Server) writeFileList(w http.ResponseWriter, r *http.Request, filter models.ListFilter) {
+	page, err := s.FilesService.List(r.Context(), filter)
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	resp := listFilesResp{
+		Files:      make([]listFilesItem, 0, len(page.Files)),
+		NextCursor: page.NextCursor,
+	}
+	for _, f := range page.Files {
+		resp.Files = append(resp.Files, listFilesItem{
+			FileID:     f.ID,
+			Name:       f.Name,
+			Bucket:     f.Bucket,
+			Key:        f.Key,
+			Size:       f.Size,
+			TotalParts: f.TotalParts,
+			CreatedAt:  f.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func parseListFilter(r *http.Request) (models.ListFilter, error) {
+	q := r.URL.Query()
+	filter := models.ListFilter{
+		Prefix: q.Get("prefix"),
+		Cursor: q.Get("cursor"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return models.ListFilter{}, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = n
+	}
+
+	switch v := models.ListSort(q.Get("sort")); v {
+	case "", models.SortByCreated, models.SortBySize, models.SortByName:
+		filter.SortBy = v
+	default:
+		return models.ListFilter{}, fmt.Errorf("sort must be %q, %q or %q", models.SortByCreated, models.SortBySize, models.SortByName)
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return models.ListFilter{}, fmt.Errorf("order must be asc or desc")
+	}
+
+	return filter.Normalize(), nil
+}
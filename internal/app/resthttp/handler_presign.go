@@ -0,0 +1,103 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+type presignRequest struct {
+	// ExpiresInSec — срок действия ссылки (0 — час, максимум — 7 суток).
+	ExpiresInSec int64 `json:"expires_in_sec"`
+	// MaxSize и FileName — только для ссылки на загрузку: предельный размер тела и имя файла.
+	MaxSize  int64  `json:"max_size"`
+	FileName string `json:"filename"`
+}
+
+type presignResp struct {
+	// URL — путь с query без схемы и хоста: клиент добавляет адрес REST сам.
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// presignDownload выдаёт ссылку на GET /files/{id} (POST /files/{id}/presign).
+func (s *Server) presignDownload(w http.ResponseWriter, r *http.Request) {
+	req, ttl, ok := s.presignParams(w, r)
+	if !ok {
+		return
+	}
+	if req.MaxSize != 0 || req.FileName != "" {
+		http.Error(w, "max_size and filename apply to upload links only", http.StatusBadRequest)
+		return
+	}
+
+	file, err := s.FilesService.Stat(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	link, expires := s.presignURL(http.MethodGet, "/files/"+file.ID, nil, ttl, time.Now())
+	writePresign(w, presignResp{URL: link, Method: http.MethodGet, ExpiresAt: expires})
+}
+
+// presignUpload выдаёт ссылку на POST /files (POST /files/presign).
+func (s *Server) presignUpload(w http.ResponseWriter, r *http.Request) {
+	req, ttl, ok := s.presignParams(w, r)
+	if !ok {
+		return
+	}
+	if req.MaxSize < 0 {
+		http.Error(w, "max_size must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	params := url.Values{}
+	if req.MaxSize > 0 {
+		params.Set(presignMaxSize, strconv.FormatInt(req.MaxSize, 10))
+	}
+	if req.FileName != "" {
+		params.Set("filename", req.FileName)
+	}
+
+	link, expires := s.presignURL(http.MethodPost, "/files", params, ttl, time.Now())
+	writePresign(w, presignResp{URL: link, Method: http.MethodPost, ExpiresAt: expires})
+}
+
+// presignParams разбирает необязательное тело запроса и срок действия ссылки.
+func (s *Server) presignParams(w http.ResponseWriter, r *http.Request) (presignRequest, time.Duration, bool) {
+	if len(s.Cfg.PresignKeys) == 0 {
+		http.Error(w, "presigned urls are not configured (presign_keys)", http.StatusNotImplemented)
+		return presignRequest{}, 0, false
+	}
+
+	var req presignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return presignRequest{}, 0, false
+	}
+
+	ttl := time.Duration(req.ExpiresInSec) * time.Second
+	switch {
+	case req.ExpiresInSec == 0:
+		ttl = defaultPresignTTL
+	case req.ExpiresInSec < 0 || ttl > maxPresignTTL:
+		http.Error(w, "expires_in_sec must be in 1..604800", http.StatusBadRequest)
+		return presignRequest{}, 0, false
+	}
+
+	return req, ttl, true
+}
+
+func writePresign(w http.ResponseWriter, resp presignResp) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,215 @@
+package resthttp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,checksum,expiration"
+	// statusChecksumMismatch — код tus checksum-расширения для тела, не совпавшего с Upload-Checksum.
+	statusChecksumMismatch = 460
+)
+
+// mountTus регистрирует tus 1.0 сервер поверх сессий составной загрузки.
+func (s *Server) mountTus(r chi.Router) {
+	r.Use(tusResumable)
+	r.Options("/", tusOptions)
+	r.Post("/", s.tusCreate)
+	r.Head("/{id}", s.tusHead)
+	r.Patch("/{id}", s.tusPatch)
+	r.Delete("/{id}", s.tusDelete)
+}
+
+// tusResumable проставляет Tus-Resumable и отклоняет клиентов несовместимой версии протокола.
+func tusResumable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		if r.Method != http.MethodOptions && r.Header.Get("Tus-Resumable") != tusVersion {
+			w.Header().Set("Tus-Version", tusVersion)
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func tusOptions(w http.ResponseWriter, _ *http.Request) {
+	h := w.Header()
+	h.Set("Tus-Version", tusVersion)
+	h.Set("Tus-Extension", tusExtensions)
+	h.Set("Tus-Checksum-Algorithm", "sha256")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tusCreate создаёт загрузку (creation): Upload-Length обязателен, отложенная длина не поддерживается.
+func (s *Server) tusCreate(w http.ResponseWriter, r *http.Request) {
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	rawMeta := r.Header.Get("Upload-Metadata")
+	meta, err := parseTusMetadata(rawMeta)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	upload, err := s.FilesService.CreateUpload(ctx, models.UploadSpec{
+		Name:     meta["filename"],
+		Length:   length,
+		Metadata: rawMeta,
+		Tus:      true,
+	})
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+	if length == 0 {
+		// Пустой файл дозаписывать нечем: завершаем сразу.
+		if _, err = s.FilesService.UploadPart(ctx, upload.ID, 1, http.NoBody, ""); err == nil {
+			_, err = s.FilesService.CompleteUpload(ctx, upload.ID)
+		}
+		if err != nil {
+			httperrors.Write(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Location", "/tus/"+upload.ID)
+	if length > 0 {
+		w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// tusHead сообщает смещение, с которого клиент продолжает загрузку.
+// Для уже завершённой загрузки смещение равно размеру готового файла; о файлах,
+// загруженных не через POST /tus, ответ — 404.
+func (s *Server) tusHead(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h := w.Header()
+	h.Set("Cache-Control", "no-store")
+
+	upload, err := s.FilesService.GetUpload(r.Context(), id)
+	if errors.Is(err, models.ErrUploadNotFound) {
+		file, statErr := s.FilesService.Stat(r.Context(), id)
+		if statErr != nil {
+			w.WriteHeader(httperrors.Status(statErr))
+			return
+		}
+		upload = models.Upload{ID: file.ID, Length: file.Size, Tus: file.Tus, Parts: file.Parts}
+		err = nil
+	}
+	if err != nil {
+		w.WriteHeader(httperrors.Status(err))
+		return
+	}
+	if !upload.Tus {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.Set("Upload-Offset", strconv.FormatInt(upload.Received(), 10))
+	h.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		h.Set("Upload-Metadata", upload.Metadata)
+	}
+	if !upload.ExpiresAt.IsZero() {
+		h.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// tusPatch дописывает тело запроса с позиции Upload-Offset.
+func (s *Server) tusPatch(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "content type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+	checksum, err := parseTusChecksum(r.Header.Get("Upload-Checksum"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	next, err := s.FilesService.AppendUpload(r.Context(), chi.URLParam(r, "id"), offset, r.Body, checksum)
+	if err != nil {
+		if errors.Is(err, models.ErrChecksum) {
+			http.Error(w, err.Error(), statusChecksumMismatch)
+			return
+		}
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(next, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tusDelete прерывает загрузку (termination).
+func (s *Server) tusDelete(w http.ResponseWriter, r *http.Request) {
+	if err := s.FilesService.AbortUpload(r.Context(), chi.URLParam(r, "id")); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseTusMetadata разбирает Upload-Metadata: пары "ключ base64(значение)" через запятую, значение необязательно.
+func parseTusMetadata(raw string) (map[string]string, error) {
+	out := make(map[string]string)
+	if strings.TrimSpace(raw) == "" {
+		return out, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, fmt.Errorf("invalid Upload-Metadata")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Upload-Metadata value for %q", key)
+		}
+		out[key] = string(decoded)
+	}
+
+	return out, nil
+}
+
+// parseTusChecksum переводит Upload-Checksum ("sha256 base64") в hex, который ожидает filesvc.
+func parseTusChecksum(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	algo, value, _ := strings.Cut(strings.TrimSpace(raw), " ")
+	if !strings.EqualFold(algo, "sha256") {
+		return "", fmt.Errorf("unsupported checksum algorithm %q", algo)
+	}
+	digest, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(digest) != 32 {
+		return "", fmt.Errorf("invalid Upload-Checksum")
+	}
+
+	return hex.EncodeToString(digest), nil
+}
@@ -0,0 +1,129 @@
+package resthttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+type uploadPartResp struct {
+	PartNumber int    `json:"part_number"`
+	Size       int64  `json:"size"`
+	Sha256     string `json:"sha256"`
+}
+
+type uploadResp struct {
+	UploadID  string           `json:"upload_id"`
+	Name      string           `json:"file_name,omitempty"`
+	Bucket    string           `json:"bucket,omitempty"`
+	Key       string           `json:"key,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	Parts     []uploadPartResp `json:"parts"`
+}
+
+// createUpload открывает сессию составной загрузки (POST /uploads).
+// С параметрами bucket и key завершённая сессия заменит объект бакета.
+func (s *Server) createUpload(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	upload, err := s.FilesService.CreateUpload(r.Context(), models.UploadSpec{
+		Name:   extractFileName(r),
+		Bucket: q.Get("bucket"),
+		Key:    q.Get("key"),
+	})
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/uploads/"+upload.ID)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(toUploadResp(upload))
+}
+
+// getUpload возвращает состояние сессии и список принятых частей.
+func (s *Server) getUpload(w http.ResponseWriter, r *http.Request) {
+	upload, err := s.FilesService.GetUpload(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toUploadResp(upload))
+}
+
+// putUploadPart принимает часть сессии; повторная отправка того же номера заменяет часть.
+func (s *Server) putUploadPart(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil {
+		http.Error(w, "invalid part number", http.StatusBadRequest)
+		return
+	}
+
+	part, err := s.FilesService.UploadPart(r.Context(), chi.URLParam(r, "id"), number, r.Body, r.Header.Get("X-Checksum-Sha256"))
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(uploadPartResp{
+		PartNumber: part.Index + 1,
+		Size:       part.Size,
+		Sha256:     part.Sha256,
+	})
+}
+
+// completeUpload собирает файл из частей сессии.
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request) {
+	res, err := s.FilesService.CompleteUpload(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(postFilesResp{
+		FileID: res.FileID,
+		Size:   res.Size,
+		Parts:  res.Parts,
+	})
+}
+
+// abortUpload отменяет сессию и удаляет её части.
+func (s *Server) abortUpload(w http.ResponseWriter, r *http.Request) {
+	if err := s.FilesService.AbortUpload(r.Context(), chi.URLParam(r, "id")); err != nil {
+		httperrors.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toUploadResp(upload models.Upload) uploadResp {
+	parts := make([]uploadPartResp, 0, len(upload.Parts))
+	for _, p := range upload.Parts {
+		parts = append(parts, uploadPartResp{PartNumber: p.Index + 1, Size: p.Size, Sha256: p.Sha256})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+
+	return uploadResp{
+		UploadID:  upload.ID,
+		Name:      upload.Name,
+		Bucket:    upload.Bucket,
+		Key:       upload.Key,
+		CreatedAt: upload.CreatedAt,
+		ExpiresAt: upload.ExpiresAt,
+		Parts:     parts,
+	}
+}
@@ -0,0 +1,143 @@
+package resthttp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/config"
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/httperrors"
+)
+
+// Параметры presigned URL; подпись покрывает метод, путь и все параметры, кроме самой подписи.
+const (
+	presignKeyID     = "X-Key-Id"
+	presignMethod    = "X-Method"
+	presignExpires   = "X-Expires"
+	presignMaxSize   = "X-Max-Size"
+	presignSignature = "X-Signature"
+
+	defaultPresignTTL = time.Hour
+	maxPresignTTL     = 7 * 24 * time.Hour
+)
+
+var (
+	errPresignInvalid = errors.New("invalid presigned url signature")
+	errPresignExpired = errors.New("presigned url has expired")
+	errPresignMethod  = errors.New("presigned url does not allow this method")
+)
+
+// presignedCtxKey помечает запрос, пропущенный по presigned URL: ключ API ему не нужен.
+type presignedCtxKey struct{}
+
+// presigned проверяет запросы с X-Signature. Неверная или просроченная подпись — 403, запрос
+// без подписи проходит дальше без изменений. Для загрузки X-Max-Size ограничивает тело.
+func (s *Server) presigned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if !q.Has(presignSignature) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := s.verifyPresigned(r.Method, r.URL.Path, q, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if v := q.Get(presignMaxSize); v != "" {
+			limit, _ := strconv.ParseInt(v, 10, 64)
+			if r.ContentLength > limit {
+				httperrors.Write(w, models.ErrTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		// Имя файла подписано в query: заголовки не должны его подменять.
+		if q.Has("filename") {
+			r.Header.Del("X-File-Name")
+			r.Header.Del("X-Filename")
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), presignedCtxKey{}, true)))
+	})
+}
+
+func isPresigned(r *http.Request) bool {
+	ok, _ := r.Context().Value(presignedCtxKey{}).(bool)
+	return ok
+}
+
+// presignURL подписывает ссылку на path для метода method первым ключом из presign_keys.
+func (s *Server) presignURL(method, path string, params url.Values, ttl time.Duration, now time.Time) (string, time.Time) {
+	key := s.Cfg.PresignKeys[0]
+	expires := now.Add(ttl).UTC().Truncate(time.Second)
+
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	q.Set(presignKeyID, key.ID)
+	q.Set(presignMethod, method)
+	q.Set(presignExpires, strconv.FormatInt(expires.Unix(), 10))
+	q.Set(presignSignature, presignSign(key.Secret, method, path, q))
+
+	return path + "?" + q.Encode(), expires
+}
+
+// verifyPresigned сверяет подпись ключом из X-Key-Id, срок действия и метод.
+// По ссылке на GET разрешён и HEAD.
+func (s *Server) verifyPresigned(method, path string, q url.Values, now time.Time) error {
+	key, ok := s.presignKey(q.Get(presignKeyID))
+	if !ok {
+		return errPresignInvalid
+	}
+	want := presignSign(key.Secret, q.Get(presignMethod), path, q)
+	if !hmac.Equal([]byte(want), []byte(q.Get(presignSignature))) {
+		return errPresignInvalid
+	}
+
+	expires, err := strconv.ParseInt(q.Get(presignExpires), 10, 64)
+	if err != nil {
+		return errPresignInvalid
+	}
+	if now.Unix() > expires {
+		return errPresignExpired
+	}
+
+	allowed := q.Get(presignMethod)
+	if method != allowed && !(method == http.MethodHead && allowed == http.MethodGet) {
+		return errPresignMethod
+	}
+
+	return nil
+}
+
+func (s *Server) presignKey(id string) (config.PresignKey, bool) {
+	for _, k := range s.Cfg.PresignKeys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return config.PresignKey{}, false
+}
+
+// presignSign — hex HMAC-SHA256 от метода, пути и отсортированных параметров без X-Signature.
+func presignSign(secret, method, path string, q url.Values) string {
+	signed := url.Values{}
+	for k, v := range q {
+		if k != presignSignature {
+			signed[k] = v
+		}
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + signed.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,118 @@
+package resthttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+var errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+
+// byteRange — полуоткрытый диапазон [start, start+length) байт файла.
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+// requestedRange определяет, какой диапазон файла отдавать.
+// partial=false означает ответ целиком: Range не задан, некорректен, состоит из нескольких
+// диапазонов или не прошёл проверку If-Range. Неудовлетворимый диапазон возвращает errRangeNotSatisfiable.
+func requestedRange(r *http.Request, file models.File) (byteRange, bool, error) {
+	full := byteRange{start: 0, length: file.Size}
+
+	header := strings.TrimSpace(r.Header.Get("Range"))
+	if header == "" {
+		return full, false, nil
+	}
+
+	// If-Range с датой или слабым/чужим ETag — отдаём файл целиком (RFC 9110, 13.1.5).
+	if ifRange := strings.TrimSpace(r.Header.Get("If-Range")); ifRange != "" && ifRange != file.ETag() {
+		return full, false, nil
+	}
+
+	rng, ok, err := parseRange(header, file.Size)
+	if err != nil {
+		return byteRange{}, false, err
+	}
+	if !ok {
+		return full, false, nil
+	}
+
+	return rng, true, nil
+}
+
+// parseRange разбирает одиночный диапазон "bytes=a-b", "bytes=a-" или "bytes=-n".
+// ok=false — заголовок синтаксически некорректен или содержит несколько диапазонов и должен игнорироваться.
+func parseRange(header string, size int64) (byteRange, bool, error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return byteRange{}, false, nil
+	}
+
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return byteRange{}, false, nil
+	}
+	first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+	if first == "" {
+		// Суффиксный диапазон: последние n байт.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return byteRange{}, false, nil
+		}
+		if n == 0 || size == 0 {
+			return byteRange{}, false, errRangeNotSatisfiable
+		}
+		n = min(n, size)
+		return byteRange{start: size - n, length: n}, true, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return byteRange{}, false, nil
+	}
+
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return byteRange{}, false, nil
+		}
+		end = min(end, size-1)
+	}
+
+	if start >= size {
+		return byteRange{}, false, errRangeNotSatisfiable
+	}
+
+	return byteRange{start: start, length: end - start + 1}, true, nil
+}
+
+// contentRange формирует значение заголовка Content-Range для частичного ответа.
+func (b byteRange) contentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(b.start, 10) + "-" + strconv.FormatInt(b.start+b.length-1, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// deferredWriter откладывает отправку статуса до первого байта тела,
+// чтобы ошибку, случившуюся до начала передачи, можно было вернуть нормальным ответом.
+type deferredWriter struct {
+	w       http.ResponseWriter
+	status  int
+	started bool
+}
+
+func (d *deferredWriter) Write(p []byte) (int, error) {
+	d.start()
+	return d.w.Write(p)
+}
+
+func (d *deferredWriter) start() {
+	if !d.started {
+		d.started = true
+		d.w.WriteHeader(d.status)
+	}
+}
@@ -0,0 +1,375 @@
+package resthttp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	sigV4Terminal  = "aws4_request"
+	sigV4Time      = "20060102T150405Z"
+
+	payloadUnsigned        = "UNSIGNED-PAYLOAD"
+	payloadStreamingSigned = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	payloadStreamingTrail  = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+
+	// maxClockSkew — допустимое расхождение часов клиента и шлюза.
+	maxClockSkew = 15 * time.Minute
+	// maxPresignExpiry — предельный срок действия presigned URL (7 суток, как в S3).
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// sigV4 — разобранные параметры подписи запроса (из Authorization или из query presigned URL).
+type sigV4 struct {
+	accessKey     string
+	date          string // YYYYMMDD из области подписи
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+	amzDate       string
+	at            time.Time
+	expires       time.Duration // только для presigned URL
+	payloadHash   string
+}
+
+func (v *sigV4) scope() string {
+	return v.date + "/" + v.region + "/" + v.service + "/" + sigV4Terminal
+}
+
+// s3Authenticate пропускает только запросы с верной подписью AWS Signature V4
+// и подменяет тело на проверяющее sha256 или подписи чанков, если клиент их прислал.
+func (s *Server) s3Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.s3Verify(r, time.Now()); err != nil {
+			writeS3Error(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) s3Verify(r *http.Request, now time.Time) error {
+	var (
+		sig *sigV4
+		err error
+	)
+	switch {
+	case strings.HasPrefix(r.Header.Get("Authorization"), sigV4Algorithm+" "):
+		sig, err = parseAuthHeader(r)
+	case r.URL.Query().Has("X-Amz-Algorithm"):
+		sig, err = parsePresigned(r.URL.Query())
+	default:
+		return errS3AccessDenied
+	}
+	if err != nil {
+		return err
+	}
+
+	secret, ok := s.s3Keys[sig.accessKey]
+	if !ok {
+		return errS3InvalidAccessKey
+	}
+	if sig.service != "s3" || sig.region != s.s3Region || sig.date != sig.amzDate[:8] {
+		return &s3Error{http.StatusBadRequest, errS3MalformedAuth.code,
+			fmt.Sprintf("credential scope %q is not valid, expected region %q and service s3", sig.scope(), s.s3Region)}
+	}
+	if sig.expires > 0 {
+		if now.Before(sig.at.Add(-maxClockSkew)) {
+			return errS3TimeSkewed
+		}
+		if now.After(sig.at.Add(sig.expires)) {
+			return errS3RequestExpired
+		}
+	} else if d := now.Sub(sig.at); d > maxClockSkew || d < -maxClockSkew {
+		return errS3TimeSkewed
+	}
+
+	key := signingKey(secret, sig.date, sig.region, sig.service)
+	want := hex.EncodeToString(hmacSHA256(key, stringToSign(sig.amzDate, sig.scope(), canonicalRequest(r, sig))))
+	if !hmac.Equal([]byte(want), []byte(sig.signature)) {
+		return errS3SignatureMismatch
+	}
+
+	return wrapPayload(r, sig, key)
+}
+
+// parseAuthHeader разбирает "AWS4-HMAC-SHA256 Credential=..., SignedHeaders=..., Signature=...".
+func parseAuthHeader(r *http.Request) (*sigV4, error) {
+	fields := make(map[string]string, 3)
+	for _, kv := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), sigV4Algorithm), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			return nil, errS3MalformedAuth
+		}
+		fields[k] = v
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if amzDate == "" {
+		// Без X-Amz-Date подписывается заголовок Date в формате HTTP.
+		t, err := http.ParseTime(r.Header.Get("Date"))
+		if err != nil {
+			return nil, &s3Error{http.StatusForbidden, errS3AccessDenied.code, "AWS authentication requires a valid Date or x-amz-date header."}
+		}
+		amzDate = t.UTC().Format(sigV4Time)
+	}
+
+	sig, err := newSigV4(fields["Credential"], fields["SignedHeaders"], fields["Signature"], amzDate)
+	if err != nil {
+		return nil, err
+	}
+	sig.payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+	if sig.payloadHash == "" {
+		return nil, s3InvalidArgument("x-amz-content-sha256 header is required")
+	}
+
+	return sig, nil
+}
+
+// parsePresigned разбирает параметры подписи presigned URL (X-Amz-*).
+func parsePresigned(q url.Values) (*sigV4, error) {
+	if q.Get("X-Amz-Algorithm") != sigV4Algorithm {
+		return nil, s3InvalidArgument("unsupported X-Amz-Algorithm")
+	}
+	sig, err := newSigV4(q.Get("X-Amz-Credential"), q.Get("X-Amz-SignedHeaders"), q.Get("X-Amz-Signature"), q.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, err
+	}
+
+	seconds, err := strconv.Atoi(q.Get("X-Amz-Expires"))
+	if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > maxPresignExpiry {
+		return nil, s3InvalidArgument("X-Amz-Expires must be between 1 and 604800 seconds")
+	}
+	sig.expires = time.Duration(seconds) * time.Second
+
+	sig.payloadHash = payloadUnsigned
+	if v := q.Get("X-Amz-Content-Sha256"); v != "" {
+		sig.payloadHash = v
+	}
+
+	return sig, nil
+}
+
+func newSigV4(credential, signedHeaders, signature, amzDate string) (*sigV4, error) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != sigV4Terminal || signedHeaders == "" || signature == "" {
+		return nil, errS3MalformedAuth
+	}
+	at, err := time.Parse(sigV4Time, amzDate)
+	if err != nil {
+		return nil, &s3Error{http.StatusForbidden, errS3AccessDenied.code, "X-Amz-Date must be in the ISO8601 basic format."}
+	}
+
+	return &sigV4{
+		accessKey:     parts[0],
+		date:          parts[1],
+		region:        parts[2],
+		service:       parts[3],
+		signedHeaders: strings.Split(signedHeaders, ";"),
+		signature:     signature,
+		amzDate:       amzDate,
+		at:            at,
+	}, nil
+}
+
+// canonicalRequest строит каноническое представление запроса по правилам SigV4 для S3
+// (путь кодируется один раз, без нормализации).
+func canonicalRequest(r *http.Request, sig *sigV4) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte('\n')
+
+	path := r.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(uriEncode(path, false))
+	b.WriteByte('\n')
+
+	// Параметры сортируются по закодированному имени, затем по значению.
+	var pairs [][2]string
+	for k, values := range r.URL.Query() {
+		if k == "X-Amz-Signature" {
+			continue
+		}
+		for _, v := range values {
+			pairs = append(pairs, [2]string{uriEncode(k, true), uriEncode(v, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	for i, p := range pairs {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(p[0] + "=" + p[1])
+	}
+	b.WriteByte('\n')
+
+	for _, name := range sig.signedHeaders {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(canonicalHeaderValue(r, name))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	b.WriteString(strings.Join(sig.signedHeaders, ";"))
+	b.WriteByte('\n')
+	b.WriteString(sig.payloadHash)
+
+	return b.String()
+}
+
+// canonicalHeaderValue возвращает значение заголовка так, как его подписал клиент.
+// net/http выносит Host, Content-Length и Transfer-Encoding из карты заголовков.
+func canonicalHeaderValue(r *http.Request, name string) string {
+	var values []string
+	switch name {
+	case "host":
+		values = []string{r.Host}
+	case "transfer-encoding":
+		values = r.TransferEncoding
+	default:
+		values = r.Header.Values(name)
+		if len(values) == 0 && name == "content-length" && r.ContentLength >= 0 {
+			values = []string{strconv.FormatInt(r.ContentLength, 10)}
+		}
+	}
+
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return strings.Join(trimmed, ",")
+}
+
+func stringToSign(amzDate, scope, canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	return sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, sigV4Terminal)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode кодирует строку по правилам SigV4: без изменений остаются только
+// A-Z, a-z, 0-9, '-', '.', '_', '~' и, если encodeSlash=false, '/'.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+
+	return b.String()
+}
+
+// wrapPayload подменяет тело запроса в зависимости от x-amz-content-sha256:
+// aws-chunked разворачивается (с проверкой подписей чанков), hex sha256 сверяется с телом.
+func wrapPayload(r *http.Request, sig *sigV4, key []byte) error {
+	switch sig.payloadHash {
+	case payloadUnsigned:
+		return nil
+	case payloadStreamingSigned, payloadStreamingTrail:
+		var signer *chunkSigner
+		if sig.payloadHash == payloadStreamingSigned {
+			signer = &chunkSigner{key: key, amzDate: sig.amzDate, scope: sig.scope(), prev: sig.signature}
+		}
+		r.Body = newChunkedReader(r.Body, signer)
+		r.ContentLength = -1
+		if v := r.Header.Get("X-Amz-Decoded-Content-Length"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				return s3InvalidArgument("x-amz-decoded-content-length must be a non-negative integer")
+			}
+			r.ContentLength = n
+		}
+		return nil
+	}
+
+	if _, err := hex.DecodeString(sig.payloadHash); err != nil || len(sig.payloadHash) != sha256.Size*2 {
+		return s3InvalidArgument("unsupported x-amz-content-sha256 value")
+	}
+	r.Body = &payloadVerifier{
+		body:   r.Body,
+		hash:   sha256.New(),
+		want:   strings.ToLower(sig.payloadHash),
+		remain: r.ContentLength,
+	}
+
+	return nil
+}
+
+// payloadVerifier сверяет sha256 тела с подписанным значением. Проверка срабатывает на EOF
+// или как только прочитан весь Content-Length — читатель может не дойти до EOF.
+type payloadVerifier struct {
+	body   io.ReadCloser
+	hash   hash.Hash
+	want   string
+	remain int64 // -1 — длина неизвестна
+	done   bool
+}
+
+func (p *payloadVerifier) Read(b []byte) (int, error) {
+	if p.done {
+		return 0, io.EOF
+	}
+
+	n, err := p.body.Read(b)
+	p.hash.Write(b[:n])
+	if p.remain >= 0 {
+		p.remain -= int64(n)
+	}
+	if err == io.EOF || (err == nil && p.remain == 0) {
+		p.done = true
+		if hex.EncodeToString(p.hash.Sum(nil)) != p.want {
+			return n, errS3ContentSHA256
+		}
+	}
+
+	return n, err
+}
+
+func (p *payloadVerifier) Close() error {
+	return p.body.Close()
+}
+
+func s3InvalidArgument(msg string) *s3Error {
+	return &s3Error{http.StatusBadRequest, "InvalidArgument", msg}
+}
@@ -0,0 +1,152 @@
+package resthttp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxAWSChunkSize ограничивает чанк, который целиком буферизуется до проверки подписи.
+	maxAWSChunkSize = 16 << 20
+	maxAWSChunkLine = 4096
+
+	// emptySHA256 — sha256 пустой строки, входит в строку подписи каждого чанка.
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// chunkSigner проверяет цепочку подписей чанков STREAMING-AWS4-HMAC-SHA256-PAYLOAD:
+// подпись каждого чанка зависит от подписи предыдущего, начиная с подписи заголовков.
+type chunkSigner struct {
+	key     []byte
+	amzDate string
+	scope   string
+	prev    string
+}
+
+func (c *chunkSigner) verify(data []byte, signature string) bool {
+	sum := sha256.Sum256(data)
+	toSign := "AWS4-HMAC-SHA256-PAYLOAD\n" + c.amzDate + "\n" + c.scope + "\n" + c.prev + "\n" + emptySHA256 + "\n" + hex.EncodeToString(sum[:])
+	want := hex.EncodeToString(hmacSHA256(c.key, toSign))
+	if !hmac.Equal([]byte(want), []byte(signature)) {
+		return false
+	}
+	c.prev = want
+
+	return true
+}
+
+// chunkedReader разворачивает тело в кодировке aws-chunked:
+// "<hex-size>[;chunk-signature=<sig>]\r\n<data>\r\n" ... "0...\r\n" [трейлеры] "\r\n".
+// Без signer подписи чанков не проверяются (STREAMING-UNSIGNED-PAYLOAD-TRAILER), трейлеры пропускаются.
+type chunkedReader struct {
+	body   io.ReadCloser
+	r      *bufio.Reader
+	signer *chunkSigner
+	buf    []byte
+	off    int
+	err    error
+}
+
+func newChunkedReader(body io.ReadCloser, signer *chunkSigner) *chunkedReader {
+	return &chunkedReader{body: body, r: bufio.NewReader(body), signer: signer}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.off == len(c.buf) {
+		if c.err != nil {
+			return 0, c.err
+		}
+		c.err = c.next()
+	}
+
+	n := copy(p, c.buf[c.off:])
+	c.off += n
+
+	return n, nil
+}
+
+func (c *chunkedReader) Close() error {
+	return c.body.Close()
+}
+
+// next читает очередной чанк в буфер. Последний (пустой) чанк завершает тело io.EOF.
+func (c *chunkedReader) next() error {
+	line, err := c.line()
+	if errors.Is(err, io.EOF) {
+		return errS3IncompleteBody
+	}
+	if err != nil {
+		return err
+	}
+
+	sizeHex, ext, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeHex), 16, 64)
+	if err != nil || size < 0 || size > maxAWSChunkSize {
+		return s3InvalidArgument("malformed aws-chunked chunk header")
+	}
+
+	if cap(c.buf) < int(size) {
+		c.buf = make([]byte, size)
+	}
+	c.buf, c.off = c.buf[:size], 0
+	if _, err = io.ReadFull(c.r, c.buf); err != nil {
+		return errS3IncompleteBody
+	}
+
+	if c.signer != nil {
+		signature, ok := strings.CutPrefix(strings.TrimSpace(ext), "chunk-signature=")
+		if !ok || !c.signer.verify(c.buf, signature) {
+			return errS3SignatureMismatch
+		}
+	}
+
+	if size == 0 {
+		// После пустого чанка идут трейлеры (например, x-amz-checksum-crc32) до пустой строки.
+		for {
+			trailer, err := c.line()
+			if errors.Is(err, io.EOF) || (err == nil && trailer == "") {
+				return io.EOF
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if crlf, err := c.line(); err != nil || crlf != "" {
+		return errS3IncompleteBody
+	}
+
+	return nil
+}
+
+// line читает строку до "\r\n" и возвращает её без разделителя.
+func (c *chunkedReader) line() (string, error) {
+	var b bytes.Buffer
+	for {
+		chunk, err := c.r.ReadSlice('\n')
+		b.Write(chunk)
+		if b.Len() > maxAWSChunkLine {
+			return "", s3InvalidArgument("aws-chunked line is too long")
+		}
+		if err == nil {
+			break
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) && b.Len() == 0 {
+			return "", io.EOF
+		}
+		return "", errS3IncompleteBody
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(b.String(), "\n"), "\r"), nil
+}
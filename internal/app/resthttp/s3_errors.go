@@ -0,0 +1,109 @@
+package resthttp
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// s3Error — ошибка в терминах S3: HTTP-статус и код из документации S3 API.
+type s3Error struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *s3Error) Error() string {
+	return e.code + ": " + e.message
+}
+
+var (
+	errS3AccessDenied       = &s3Error{http.StatusForbidden, "AccessDenied", "Access Denied."}
+	errS3InvalidAccessKey   = &s3Error{http.StatusForbidden, "InvalidAccessKeyId", "The access key ID you provided does not exist in our records."}
+	errS3SignatureMismatch  = &s3Error{http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided."}
+	errS3RequestExpired     = &s3Error{http.StatusForbidden, "AccessDenied", "Request has expired."}
+	errS3TimeSkewed         = &s3Error{http.StatusForbidden, "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large."}
+	errS3MalformedAuth      = &s3Error{http.StatusBadRequest, "AuthorizationHeaderMalformed", "The authorization header is malformed."}
+	errS3ContentSHA256      = &s3Error{http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed."}
+	errS3IncompleteBody     = &s3Error{http.StatusBadRequest, "IncompleteBody", "The request body terminated unexpectedly."}
+	errS3InvalidBucketName  = &s3Error{http.StatusBadRequest, "InvalidBucketName", "The specified bucket is not valid."}
+	errS3BucketNotEmpty     = &s3Error{http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty."}
+	errS3BucketOwned        = &s3Error{http.StatusConflict, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it."}
+	errS3NoSuchBucket       = &s3Error{http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist."}
+	errS3NoSuchKey          = &s3Error{http.StatusNotFound, "NoSuchKey", "The specified key does not exist."}
+	errS3NoSuchUpload       = &s3Error{http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist."}
+	errS3InvalidPart        = &s3Error{http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found."}
+	errS3InvalidPartOrder   = &s3Error{http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order."}
+	errS3MalformedXML       = &s3Error{http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed."}
+	errS3BadDigest          = &s3Error{http.StatusBadRequest, "BadDigest", "The Content-SHA256 you specified did not match what we received."}
+	errS3EntityTooLarge     = &s3Error{http.StatusBadRequest, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed object size."}
+	errS3InvalidRange       = &s3Error{http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable."}
+	errS3NotImplemented     = &s3Error{http.StatusNotImplemented, "NotImplemented", "A header or query you provided implies functionality that is not implemented."}
+	errS3MethodNotAllowed   = &s3Error{http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource."}
+	errS3ServiceUnavailable = &s3Error{http.StatusServiceUnavailable, "ServiceUnavailable", "Please reduce your request rate."}
+	errS3InternalError      = &s3Error{http.StatusInternalServerError, "InternalError", "We encountered an internal error. Please try again."}
+)
+
+type s3ErrorResp struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource,omitempty"`
+}
+
+// s3ErrorOf переводит ошибку сервиса в ошибку S3.
+func s3ErrorOf(err error) *s3Error {
+	var s3err *s3Error
+	switch {
+	case errors.As(err, &s3err):
+		return s3err
+	case errors.Is(err, models.ErrNotFound):
+		return errS3NoSuchKey
+	case errors.Is(err, models.ErrUploadNotFound):
+		return errS3NoSuchUpload
+	case errors.Is(err, models.ErrBucketNotFound):
+		return errS3NoSuchBucket
+	case errors.Is(err, models.ErrBucketExists):
+		return errS3BucketOwned
+	case errors.Is(err, models.ErrBucketNotEmpty):
+		return errS3BucketNotEmpty
+	case errors.Is(err, models.ErrInvalidName):
+		return s3InvalidArgument(err.Error())
+	case errors.Is(err, models.ErrChecksum):
+		return errS3BadDigest
+	case errors.Is(err, models.ErrTooLarge):
+		return errS3EntityTooLarge
+	case errors.Is(err, models.ErrInvalidPart):
+		return &s3Error{http.StatusBadRequest, errS3InvalidPart.code, err.Error()}
+	case errors.Is(err, models.ErrBadCursor):
+		return s3InvalidArgument(err.Error())
+	case errors.Is(err, models.ErrNoStorage):
+		return errS3ServiceUnavailable
+	default:
+		return &s3Error{http.StatusInternalServerError, errS3InternalError.code, err.Error()}
+	}
+}
+
+// writeS3Error отвечает XML-документом Error; на HEAD — только статусом.
+func writeS3Error(w http.ResponseWriter, r *http.Request, err error) {
+	s3err := s3ErrorOf(err)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(s3err.status)
+		return
+	}
+
+	writeXML(w, s3err.status, s3ErrorResp{
+		Code:     s3err.code,
+		Message:  s3err.message,
+		Resource: r.URL.Path,
+	})
+}
+
+func writeXML(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,278 @@
+package resthttp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// s3MaxKeys — предельный размер страницы ListObjects.
+const s3MaxKeys = 1000
+
+// afterPrefix — суффикс, которым курсор перескакивает через все ключи общего префикса.
+const afterPrefix = "\U0010FFFF"
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// s3ListBucketResult — ответ ListObjects (v1) и ListObjectsV2: у каждой версии свой набор полей.
+type s3ListBucketResult struct {
+	XMLName   xml.Name `xml:"ListBucketResult"`
+	Namespace string   `xml:"xmlns,attr"`
+	Name      string   `xml:"Name"`
+	Prefix    string   `xml:"Prefix"`
+	Delimiter string   `xml:"Delimiter,omitempty"`
+	MaxKeys   int      `xml:"MaxKeys"`
+	KeyCount  *int     `xml:"KeyCount,omitempty"`
+
+	Marker                *string `xml:"Marker,omitempty"`
+	NextMarker            string  `xml:"NextMarker,omitempty"`
+	ContinuationToken     string  `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string  `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string  `xml:"StartAfter,omitempty"`
+	EncodingType          string  `xml:"EncodingType,omitempty"`
+
+	IsTruncated    bool             `xml:"IsTruncated"`
+	Contents       []s3Object       `xml:"Contents"`
+	CommonPrefixes []s3CommonPrefix `xml:"CommonPrefixes"`
+}
+
+type s3LocationResp struct {
+	XMLName   xml.Name `xml:"LocationConstraint"`
+	Namespace string   `xml:"xmlns,attr"`
+	Region    string   `xml:",chardata"`
+}
+
+type s3Bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type s3ListAllMyBucketsResult struct {
+	XMLName   xml.Name   `xml:"ListAllMyBucketsResult"`
+	Namespace string     `xml:"xmlns,attr"`
+	Buckets   []s3Bucket `xml:"Buckets>Bucket"`
+}
+
+func (s *Server) s3ListBuckets(w http.ResponseWriter, r *http.Request) {
+	buckets, err := s.FilesService.ListBuckets(r.Context())
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	resp := s3ListAllMyBucketsResult{Namespace: s3Namespace}
+	for _, b := range buckets {
+		resp.Buckets = append(resp.Buckets, s3Bucket{Name: b.Name, CreationDate: b.CreatedAt.UTC().Format(s3TimeFormat)})
+	}
+
+	writeXML(w, http.StatusOK, resp)
+}
+
+func (s *Server) s3CreateBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	if _, err := s.FilesService.CreateBucket(r.Context(), bucket); err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+// s3DeleteBucket разрешает удалять только пустой бакет.
+func (s *Server) s3DeleteBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	if err := s.FilesService.DeleteBucket(r.Context(), bucket); err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) s3BucketLocation(w http.ResponseWriter) {
+	// Для us-east-1 S3 возвращает пустой LocationConstraint.
+	region := s.s3Region
+	if region == defaultS3Region {
+		region = ""
+	}
+	writeXML(w, http.StatusOK, s3LocationResp{Namespace: s3Namespace, Region: region})
+}
+
+// s3ListObjects отвечает на ListObjectsV2 (list-type=2) и ListObjects v1 (marker).
+func (s *Server) s3ListObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	q := r.URL.Query()
+	v2 := q.Get("list-type") == "2"
+
+	maxKeys := s3MaxKeys
+	if v := q.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeS3Error(w, r, s3InvalidArgument("max-keys must be a non-negative integer"))
+			return
+		}
+		maxKeys = min(n, s3MaxKeys)
+	}
+
+	encodeURL := false
+	switch q.Get("encoding-type") {
+	case "":
+	case "url":
+		encodeURL = true
+	default:
+		writeS3Error(w, r, s3InvalidArgument("encoding-type must be url"))
+		return
+	}
+	enc := func(s string) string {
+		if encodeURL {
+			return uriEncode(s, false)
+		}
+		return s
+	}
+
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	resp := s3ListBucketResult{
+		Namespace: s3Namespace,
+		Name:      bucket,
+		Prefix:    enc(prefix),
+		Delimiter: enc(delimiter),
+		MaxKeys:   maxKeys,
+	}
+	if encodeURL {
+		resp.EncodingType = "url"
+	}
+
+	var after string
+	if v2 {
+		after = q.Get("start-after")
+		resp.StartAfter = enc(after)
+		if token := q.Get("continuation-token"); token != "" {
+			raw, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				writeS3Error(w, r, s3InvalidArgument("The continuation token provided is incorrect"))
+				return
+			}
+			after = string(raw)
+			resp.ContinuationToken = token
+		}
+	} else {
+		after = q.Get("marker")
+		marker := enc(after)
+		resp.Marker = &marker
+	}
+
+	if _, err := s.FilesService.GetBucket(r.Context(), bucket); err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+	listing, err := s.s3List(r.Context(), bucket, prefix, delimiter, after, maxKeys)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	resp.IsTruncated = listing.truncated
+	for _, f := range listing.objects {
+		resp.Contents = append(resp.Contents, s3Object{
+			Key:          enc(strings.TrimPrefix(f.Name, bucket+"/")),
+			LastModified: f.CreatedAt.UTC().Format(s3TimeFormat),
+			Size:         f.Size,
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, p := range listing.prefixes {
+		resp.CommonPrefixes = append(resp.CommonPrefixes, s3CommonPrefix{Prefix: enc(p)})
+	}
+
+	if v2 {
+		count := len(resp.Contents) + len(resp.CommonPrefixes)
+		resp.KeyCount = &count
+		if listing.truncated {
+			resp.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(listing.next))
+		}
+	} else if listing.truncated && delimiter != "" {
+		// Без разделителя клиент v1 продолжает с последнего ключа страницы сам.
+		resp.NextMarker = enc(listing.next)
+	}
+
+	writeXML(w, http.StatusOK, resp)
+}
+
+// s3Listing — страница ключей бакета в побайтовом порядке.
+type s3Listing struct {
+	objects   []models.File
+	prefixes  []string
+	truncated bool
+	// next — позиция, после которой продолжать: последний ключ страницы
+	// или общий префикс с afterPrefix, чтобы пропустить все ключи под ним.
+	next string
+}
+
+// s3List перечисляет ключи бакета после after. При непустом delimiter ключи,
+// содержащие его после prefix, сворачиваются в общие префиксы.
+func (s *Server) s3List(ctx context.Context, bucket, prefix, delimiter, after string, maxKeys int) (s3Listing, error) {
+	var (
+		out        s3Listing
+		lastKey    string
+		lastPrefix string
+	)
+
+	filter := models.ListFilter{Bucket: bucket, Prefix: prefix, After: after, SortBy: models.SortByName, Limit: models.MaxListLimit}
+
+	for {
+		page, err := s.FilesService.List(ctx, filter)
+		if err != nil {
+			return s3Listing{}, err
+		}
+
+		for _, f := range page.Files {
+			key := f.Key
+			if key == lastKey || (lastPrefix != "" && strings.HasPrefix(key, lastPrefix)) {
+				continue
+			}
+
+			common := ""
+			if delimiter != "" {
+				if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+					common = key[:len(prefix)+i+len(delimiter)]
+				}
+			}
+
+			if len(out.objects)+len(out.prefixes) == maxKeys {
+				out.truncated = true
+				return out, nil
+			}
+			if common != "" {
+				out.prefixes = append(out.prefixes, common)
+				lastPrefix, out.next = common, common+afterPrefix
+			} else {
+				out.objects = append(out.objects, f)
+				lastKey, out.next = key, key
+			}
+		}
+
+		if page.NextCursor == "" {
+			return out, nil
+		}
+
+		// Страница кончилась внутри общего префикса — перескакиваем через оставшиеся под ним ключи.
+		last := page.Files[len(page.Files)-1].Key
+		if jump := lastPrefix + afterPrefix; lastPrefix != "" && strings.HasPrefix(last, lastPrefix) && last < jump {
+			filter.After, filter.Cursor = jump, ""
+			continue
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
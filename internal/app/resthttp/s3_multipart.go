@@ -0,0 +1,206 @@
+package resthttp
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// maxCompleteBodyBytes ограничивает XML со списком частей (10000 частей с запасом).
+const maxCompleteBodyBytes = 2 << 20
+
+type s3InitiateMultipartResp struct {
+	XMLName   xml.Name `xml:"InitiateMultipartUploadResult"`
+	Namespace string   `xml:"xmlns,attr"`
+	Bucket    string   `xml:"Bucket"`
+	Key       string   `xml:"Key"`
+	UploadID  string   `xml:"UploadId"`
+}
+
+type s3CompleteMultipartReq struct {
+	Parts []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type s3CompleteMultipartResp struct {
+	XMLName   xml.Name `xml:"CompleteMultipartUploadResult"`
+	Namespace string   `xml:"xmlns,attr"`
+	Location  string   `xml:"Location"`
+	Bucket    string   `xml:"Bucket"`
+	Key       string   `xml:"Key"`
+	ETag      string   `xml:"ETag"`
+}
+
+type s3Part struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+	Size       int64  `xml:"Size"`
+}
+
+type s3ListPartsResp struct {
+	XMLName     xml.Name `xml:"ListPartsResult"`
+	Namespace   string   `xml:"xmlns,attr"`
+	Bucket      string   `xml:"Bucket"`
+	Key         string   `xml:"Key"`
+	UploadID    string   `xml:"UploadId"`
+	MaxParts    int      `xml:"MaxParts"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	Parts       []s3Part `xml:"Part"`
+}
+
+// s3CreateMultipart открывает сессию составной загрузки; UploadId — идентификатор сессии.
+func (s *Server) s3CreateMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	upload, err := s.FilesService.CreateUpload(r.Context(), models.UploadSpec{Bucket: bucket, Key: key})
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	writeXML(w, http.StatusOK, s3InitiateMultipartResp{
+		Namespace: s3Namespace,
+		Bucket:    bucket,
+		Key:       key,
+		UploadID:  upload.ID,
+	})
+}
+
+func (s *Server) s3UploadPart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	upload, err := s.s3Upload(r.Context(), r.URL.Query().Get("uploadId"), bucket, key)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number < 1 || number > models.MaxUploadParts {
+		writeS3Error(w, r, s3InvalidArgument("Part number must be an integer between 1 and 10000, inclusive"))
+		return
+	}
+
+	part, err := s.FilesService.UploadPart(r.Context(), upload.ID, number, r.Body, "")
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", s3PartETag(part))
+	w.WriteHeader(http.StatusOK)
+}
+
+// s3CompleteMultipart собирает объект. Сервис собирает файл из всех принятых частей 1..N,
+// поэтому список в запросе должен перечислять их все подряд и с верными ETag.
+func (s *Server) s3CompleteMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	upload, err := s.s3Upload(r.Context(), r.URL.Query().Get("uploadId"), bucket, key)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	var req s3CompleteMultipartReq
+	if err = xml.NewDecoder(io.LimitReader(r.Body, maxCompleteBodyBytes)).Decode(&req); err != nil || len(req.Parts) == 0 {
+		writeS3Error(w, r, errS3MalformedXML)
+		return
+	}
+	for i, p := range req.Parts {
+		if i > 0 && p.PartNumber <= req.Parts[i-1].PartNumber {
+			writeS3Error(w, r, errS3InvalidPartOrder)
+			return
+		}
+		part, ok := upload.Parts[p.PartNumber-1]
+		if !ok || strings.Trim(p.ETag, `"`) != strings.Trim(s3PartETag(part), `"`) {
+			writeS3Error(w, r, errS3InvalidPart)
+			return
+		}
+		if p.PartNumber != i+1 {
+			writeS3Error(w, r, &s3Error{http.StatusBadRequest, errS3InvalidPart.code, "parts must be numbered 1..N without gaps"})
+			return
+		}
+	}
+	if len(req.Parts) != len(upload.Parts) {
+		writeS3Error(w, r, &s3Error{http.StatusBadRequest, errS3InvalidPart.code, "all uploaded parts must be listed"})
+		return
+	}
+
+	res, err := s.FilesService.CompleteUpload(r.Context(), upload.ID)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+	file, err := s.FilesService.Stat(r.Context(), res.FileID)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	writeXML(w, http.StatusOK, s3CompleteMultipartResp{
+		Namespace: s3Namespace,
+		Location:  "/" + bucket + "/" + key,
+		Bucket:    bucket,
+		Key:       key,
+		ETag:      s3ETag(file),
+	})
+}
+
+func (s *Server) s3AbortMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	upload, err := s.s3Upload(r.Context(), r.URL.Query().Get("uploadId"), bucket, key)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+	if err = s.FilesService.AbortUpload(r.Context(), upload.ID); err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) s3ListParts(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	upload, err := s.s3Upload(r.Context(), r.URL.Query().Get("uploadId"), bucket, key)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	resp := s3ListPartsResp{
+		Namespace: s3Namespace,
+		Bucket:    bucket,
+		Key:       key,
+		UploadID:  upload.ID,
+		MaxParts:  models.MaxUploadParts,
+		Parts:     make([]s3Part, 0, len(upload.Parts)),
+	}
+	for idx, part := range upload.Parts {
+		resp.Parts = append(resp.Parts, s3Part{PartNumber: idx + 1, ETag: s3PartETag(part), Size: part.Size})
+	}
+	sort.Slice(resp.Parts, func(i, j int) bool {
+		return resp.Parts[i].PartNumber < resp.Parts[j].PartNumber
+	})
+
+	writeXML(w, http.StatusOK, resp)
+}
+
+// s3Upload возвращает сессию из uploadId, если она открыта для этого же ключа.
+func (s *Server) s3Upload(ctx context.Context, uploadID, bucket, key string) (models.Upload, error) {
+	upload, err := s.FilesService.GetUpload(ctx, uploadID)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	if upload.Bucket != bucket || upload.Key != key {
+		return models.Upload{}, errS3NoSuchUpload
+	}
+
+	return upload, nil
+}
+
+// s3PartETag — ETag части: её sha256, а не MD5, поэтому клиенты его не сверяют с содержимым.
+func s3PartETag(part models.Part) string {
+	return `"` + part.Sha256 + `"`
+}
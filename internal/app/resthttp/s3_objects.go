@@ -0,0 +1,102 @@
+package resthttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// s3TimeFormat — формат дат в XML-ответах S3.
+const s3TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// s3PutObject загружает объект целиком, заменяя прежний с тем же ключом.
+func (s *Server) s3PutObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	res, err := s.FilesService.PutObject(r.Context(), bucket, key, r.Body, r.ContentLength)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+	file, err := s.FilesService.Stat(r.Context(), res.FileID)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", s3ETag(file))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) s3GetObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	file, err := s.FilesService.StatObject(r.Context(), bucket, key)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	rng, partial, err := requestedRange(r, file)
+	if err != nil {
+		w.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(file.Size, 10))
+		writeS3Error(w, r, errS3InvalidRange)
+		return
+	}
+
+	h := w.Header()
+	setS3ObjectHeaders(h, file)
+	h.Set("Content-Length", strconv.FormatInt(rng.length, 10))
+
+	out := &deferredWriter{w: w, status: http.StatusOK}
+	if partial {
+		h.Set("Content-Range", rng.contentRange(file.Size))
+		out.status = http.StatusPartialContent
+	}
+
+	if err = s.FilesService.StreamRange(r.Context(), file, rng.start, rng.length, out); err != nil {
+		if out.started {
+			panic(http.ErrAbortHandler)
+		}
+		for _, k := range []string{"Accept-Ranges", "ETag", "Last-Modified", "Content-Type", "Content-Length", "Content-Range"} {
+			h.Del(k)
+		}
+		writeS3Error(w, r, err)
+		return
+	}
+	out.start()
+}
+
+func (s *Server) s3HeadObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	file, err := s.FilesService.StatObject(r.Context(), bucket, key)
+	if err != nil {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	setS3ObjectHeaders(w.Header(), file)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// s3DeleteObject удаляет объект. Отсутствующий ключ — не ошибка, как в S3.
+func (s *Server) s3DeleteObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	if err := s.FilesService.DeleteObject(r.Context(), bucket, key); err != nil && !errors.Is(err, models.ErrNotFound) {
+		writeS3Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// s3ETag оформляет ETag файла как у составного объекта S3 ("<hash>-<parts>"),
+// чтобы клиенты не принимали его за MD5 содержимого.
+func s3ETag(file models.File) string {
+	return strings.TrimSuffix(file.ETag(), `"`) + "-" + strconv.Itoa(file.TotalParts) + `"`
+}
+
+func setS3ObjectHeaders(h http.Header, file models.File) {
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("ETag", s3ETag(file))
+	h.Set("Last-Modified", file.CreatedAt.UTC().Format(http.TimeFormat))
+	h.Set("Content-Type", "application/octet-stream")
+}
@@ -0,0 +1,145 @@
+package resthttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/internal/config"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	s3Namespace     = "http://s3.amazonaws.com/doc/2006-03-01/"
+)
+
+// s3Unsupported — подресурсы бакета, которые шлюз не реализует.
+var s3Unsupported = []string{"acl", "cors", "encryption", "lifecycle", "logging", "notification",
+	"object-lock", "policy", "replication", "tagging", "uploads", "versioning", "versions", "website"}
+
+// NewS3Server конструирует S3-совместимый шлюз поверх того же сервиса файлов.
+// Адресация path-style: /{bucket}/{key}; бакеты и ключи — те же, что у /buckets в REST API.
+func NewS3Server(cfg *config.Config) (http.Handler, *Server, error) {
+	if len(cfg.S3Credentials) == 0 {
+		return nil, nil, fmt.Errorf("s3_credentials are required")
+	}
+	keys := make(map[string]string, len(cfg.S3Credentials))
+	for _, c := range cfg.S3Credentials {
+		if c.AccessKey == "" || c.SecretKey == "" {
+			return nil, nil, fmt.Errorf("s3 credential must have both access_key and secret_key")
+		}
+		keys[c.AccessKey] = c.SecretKey
+	}
+
+	srv, err := newServer(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	srv.s3Keys = keys
+	srv.s3Region = cfg.S3Region
+	if srv.s3Region == "" {
+		srv.s3Region = defaultS3Region
+	}
+
+	rtr := chi.NewRouter()
+	rtr.Use(srv.s3Authenticate)
+	rtr.HandleFunc("/*", srv.s3Dispatch)
+
+	return rtr, srv, nil
+}
+
+// s3Dispatch выбирает операцию S3 по методу, пути и подресурсам в query.
+func (s *Server) s3Dispatch(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+
+	switch {
+	case bucket == "":
+		if r.Method != http.MethodGet {
+			writeS3Error(w, r, errS3MethodNotAllowed)
+			return
+		}
+		s.s3ListBuckets(w, r)
+	case !models.ValidBucketName(bucket):
+		writeS3Error(w, r, errS3InvalidBucketName)
+	case key == "":
+		s.s3BucketOp(w, r, bucket, q)
+	default:
+		s.s3ObjectOp(w, r, bucket, key, q)
+	}
+}
+
+func (s *Server) s3BucketOp(w http.ResponseWriter, r *http.Request, bucket string, q url.Values) {
+	for _, sub := range s3Unsupported {
+		if _, ok := q[sub]; ok {
+			writeS3Error(w, r, errS3NotImplemented)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.s3CreateBucket(w, r, bucket)
+	case http.MethodHead:
+		if _, err := s.FilesService.GetBucket(r.Context(), bucket); err != nil {
+			writeS3Error(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		s.s3DeleteBucket(w, r, bucket)
+	case http.MethodGet:
+		if _, ok := q["location"]; ok {
+			s.s3BucketLocation(w)
+			return
+		}
+		s.s3ListObjects(w, r, bucket)
+	default:
+		writeS3Error(w, r, errS3MethodNotAllowed)
+	}
+}
+
+func (s *Server) s3ObjectOp(w http.ResponseWriter, r *http.Request, bucket, key string, q url.Values) {
+	_, multipart := q["uploadId"]
+
+	switch r.Method {
+	case http.MethodPut:
+		switch {
+		case r.Header.Get("X-Amz-Copy-Source") != "":
+			writeS3Error(w, r, errS3NotImplemented)
+		case multipart:
+			s.s3UploadPart(w, r, bucket, key)
+		default:
+			s.s3PutObject(w, r, bucket, key)
+		}
+	case http.MethodGet:
+		if multipart {
+			s.s3ListParts(w, r, bucket, key)
+			return
+		}
+		s.s3GetObject(w, r, bucket, key)
+	case http.MethodHead:
+		s.s3HeadObject(w, r, bucket, key)
+	case http.MethodDelete:
+		if multipart {
+			s.s3AbortMultipart(w, r, bucket, key)
+			return
+		}
+		s.s3DeleteObject(w, r, bucket, key)
+	case http.MethodPost:
+		if _, ok := q["uploads"]; ok {
+			s.s3CreateMultipart(w, r, bucket, key)
+			return
+		}
+		if multipart {
+			s.s3CompleteMultipart(w, r, bucket, key)
+			return
+		}
+		writeS3Error(w, r, errS3NotImplemented)
+	default:
+		writeS3Error(w, r, errS3MethodNotAllowed)
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sir_venger/s3_lite/internal/config"
+	"github.com/sir_venger/s3_lite/internal/models"
 	"github.com/sir_venger/s3_lite/internal/repo/meta"
 	"github.com/sir_venger/s3_lite/internal/usecase/filesvc"
 	adapters "github.com/sir_venger/s3_lite/internal/usecase/filesvc/adapters/storage"
@@ -20,76 +22,165 @@ const defaultFileParts = 6
 type Server struct {
 	FilesService filesvc.Service
 	Cfg          *config.Config
-}
 
-type addStoragesRequest struct {
-	Storages []string `json:"storages"`
+	metaStore meta.Store
+	stopRetry func()
+	// stopExpiry останавливает удаление брошенных сессий составной загрузки.
+	stopExpiry func()
+
+	// health — фоновый монитор стораджей, из кеша которого выбираются узлы для записи.
+	health     *adapters.HealthMonitor
+	stopHealth func()
+	stopSync   func()
+
+	// s3Keys — секреты S3-шлюза по access key, s3Region — регион в области подписи.
+	s3Keys   map[string]string
+	s3Region string
 }
 
 // NewServer конструктор
 func NewServer(cfg *config.Config) (http.Handler, *Server, error) {
-	files, err := buildFileService(cfg)
+	srv, err := newServer(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	srv := &Server{
-		FilesService: files,
-		Cfg:          cfg,
-	}
-
 	rtr := chi.NewRouter()
+	if len(cfg.PresignKeys) > 0 {
+		rtr.Use(srv.presigned)
+	}
+	if cfg.AuthEnabled {
+		rtr.Use(srv.authenticate)
+	}
 	rtr.Post("/files", srv.postFiles)
+	rtr.Post("/files/presign", srv.presignUpload)
+	rtr.Post("/files/{id}/presign", srv.presignDownload)
+	rtr.Get("/files", srv.listFiles)
 	rtr.Get("/files/{id}", srv.getFile)
-	rtr.Get("/admin/config", func(w http.ResponseWriter, r *http.Request) { _ = json.NewEncoder(w).Encode(cfg) })
-	rtr.Post("/admin/storages", srv.addStorages)
+	rtr.Head("/files/{id}", srv.headFile)
+	rtr.Get("/files/{id}/meta", srv.getFileMeta)
+	rtr.Delete("/files/{id}", srv.deleteFile)
+	rtr.Post("/uploads", srv.createUpload)
+	rtr.Get("/uploads/{id}", srv.getUpload)
+	rtr.Put("/uploads/{id}/parts/{n}", srv.putUploadPart)
+	rtr.Post("/uploads/{id}/complete", srv.completeUpload)
+	rtr.Delete("/uploads/{id}", srv.abortUpload)
+	rtr.Route("/tus", srv.mountTus)
+	rtr.Route("/buckets", srv.mountBuckets)
+	// /admin/* требует ключ с правом admin и при выключенной проверке на остальных маршрутах:
+	// без admin_api_key и выпущенных ключей админка закрыта.
+	rtr.Group(func(r chi.Router) {
+		if !cfg.AuthEnabled {
+			r.Use(srv.authenticate)
+		}
+		r.Get("/admin/config", func(w http.ResponseWriter, r *http.Request) { _ = json.NewEncoder(w).Encode(cfg.Redacted()) })
+		r.Route("/admin/storages", srv.mountAdminStorages)
+		r.Route("/admin/keys", srv.mountAdminKeys)
+	})
 
 	return rtr, srv, nil
 }
 
-func buildFileService(cfg *config.Config) (filesvc.Service, error) {
-	var (
-		repo filesvc.MetaStorage
-		err  error
-	)
+func newServer(cfg *config.Config) (*Server, error) {
+	monitor := adapters.NewHealthMonitor(cfg.StorageMinFreeBytes, time.Duration(cfg.HealthCheckIntervalSec)*time.Second)
+	files, store, err := buildFileService(cfg, monitor)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := files.ListStorages(context.Background())
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	monitor.Track(nodeURLs(nodes))
+
+	return &Server{
+		FilesService: files,
+		Cfg:          cfg,
+		metaStore:    store,
+		stopRetry:    filesvc.StartDeletionRetry(files, time.Duration(cfg.DeleteRetryIntervalSec)*time.Second),
+		stopExpiry:   filesvc.StartUploadExpiry(files, time.Duration(cfg.DeleteRetryIntervalSec)*time.Second),
+		health:       monitor,
+		stopHealth:   monitor.Start(),
+		// Состав кластера перечитывается с периодом опроса health: изменения через другие реплики REST доходят сюда.
+		stopSync: filesvc.StartStorageSync(files, monitor.Interval, func(nodes []models.StorageNode) {
+			monitor.Track(nodeURLs(nodes))
+		}),
+	}, nil
+}
+
+// Close останавливает фоновые задачи и освобождает ресурсы хранилища метаданных.
+func (s *Server) Close() {
+	if s.stopRetry != nil {
+		s.stopRetry()
+	}
+	if s.stopExpiry != nil {
+		s.stopExpiry()
+	}
+	if s.stopSync != nil {
+		s.stopSync()
+	}
+	if s.stopHealth != nil {
+		s.stopHealth()
+	}
+	if s.metaStore != nil {
+		s.metaStore.Close()
+	}
+}
+
+func buildFileService(cfg *config.Config, monitor *adapters.HealthMonitor) (filesvc.Service, meta.Store, error) {
 	ctx := context.Background()
 
 	metaDSN := strings.TrimSpace(cfg.MetaDSN)
 	if metaDSN == "" {
-		return nil, fmt.Errorf("meta_dsn is required")
+		return nil, nil, fmt.Errorf("meta_dsn is required")
 	}
 
-	repo, err = meta.NewPGStore(ctx, metaDSN)
+	// Бэкенд выбирается по схеме DSN: postgres://, memory://<name> и т.д.
+	repo, err := meta.Open(ctx, metaDSN)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	cli := storageclient.New()
-	adapter := adapters.NewHealthAdapter(0)
-	r := filesvc.NewRouter(adapter)
+	cli := adapters.ObserveClient(storageclient.New(), monitor)
+	r := filesvc.NewRouter(monitor)
 
 	fileManager := filesvc.New(filesvc.Deps{
 		MetaStorage: repo,
+		Deletions:   repo,
+		Uploads:     repo,
+		Buckets:     repo,
+		Membership:  repo,
+		Keys:        repo,
 		Router:      r,
 		StorageCli:  cli,
 		Parts:       defaultFileParts,
-	})
 
-	fileManager.Router.Set(cfg.Storages)
-	return fileManager, nil
-}
+		StreamPartSize: cfg.StreamPartSizeBytes,
+		SpoolDir:       cfg.SpoolDir,
 
-func (s *Server) addStorages(w http.ResponseWriter, r *http.Request) {
-	var payload addStoragesRequest
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	if len(payload.Storages) == 0 {
-		http.Error(w, "storages list is empty", http.StatusBadRequest)
-		return
-	}
+		MaxUploadPartSize: cfg.MaxUploadPartBytes,
+		UploadTTL:         time.Duration(cfg.UploadTTLSec) * time.Second,
+
+		ReplicationFactor: cfg.ReplicationFactor,
+		WriteQuorum:       cfg.WriteQuorum,
+
+		ErasureDataShards:   cfg.ErasureDataShards,
+		ErasureParityShards: cfg.ErasureParityShards,
+		ErasureWriteQuorum:  cfg.ErasureWriteQuorum,
+		ErasureStripeSize:   cfg.ErasureStripeBytes,
 
-	s.FilesService.AddStorages(payload.Storages...)
-	w.WriteHeader(http.StatusNoContent)
+		UploadConcurrency: cfg.UploadConcurrency,
+		UploadMemoryLimit: cfg.UploadMemoryLimitBytes,
+
+		DownloadPrefetch:    cfg.DownloadPrefetch,
+		DownloadBufferBytes: cfg.DownloadBufferBytes,
+	})
+
+	// storages из конфига заполняют только пустой состав кластера, дальше он хранится в метаданных.
+	if err = fileManager.InitStorages(ctx, cfg.Storages); err != nil {
+		repo.Close()
+		return nil, nil, err
+	}
+	return fileManager, repo, nil
 }
@@ -0,0 +1,81 @@
+package storagehttp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// createTemp создаёт временный файл рядом с path: после записи он переименовывается на место path,
+// так что читатели видят либо прежнюю, либо полностью записанную версию.
+func createTemp(path string) (*os.File, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+tempFileMarker+"*")
+	if err != nil {
+		return nil, err
+	}
+	// CreateTemp создаёт файл с 0600, а части и meta.json всегда были 0644.
+	if err = f.Chmod(0o644); err != nil {
+		discardTemp(f)
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// isTempFile сообщает, что имя принадлежит недописанному временному файлу.
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, tempFileMarker)
+}
+
+// commitTemp сбрасывает временный файл на диск, переименовывает его в path
+// и синхронизирует каталог, чтобы переименование пережило сбой питания.
+// Временный файл удаляется при любой ошибке.
+func commitTemp(f *os.File, path string) error {
+	err := f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// discardTemp закрывает и удаляет временный файл, который не пошёл в дело.
+func discardTemp(f *os.File) {
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+// writeFileAtomic заменяет содержимое path через временный файл и rename.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := createTemp(path)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		discardTemp(f)
+		return err
+	}
+
+	return commitTemp(f, path)
+}
+
+// syncDir сбрасывает на диск записи каталога (создание, переименование файлов).
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
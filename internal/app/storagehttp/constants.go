@@ -3,4 +3,6 @@ package storagehttp
 const (
 	metaFileName       = "meta.json"
 	partFilenameFormat = "%d.part"
+	// tempFileMarker отличает недописанные временные файлы: ".<имя>.tmp-<случайный суффикс>".
+	tempFileMarker = ".tmp-"
 )
@@ -0,0 +1,123 @@
+package storagehttp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*dataDir{}
+)
+
+// dataDir — общее для процесса состояние каталога данных: блокировки файлов и счётчики
+// занятого места. Его делят HTTP-обработчики и GC, работающие с одним каталогом.
+type dataDir struct {
+	root  string
+	locks *fileLocks
+	usage *diskUsage
+}
+
+// diskUsage — занятое частями место, поддерживаемое инкрементально при записи и удалении,
+// чтобы health не обходил весь каталог на каждый запрос.
+type diskUsage struct {
+	files atomic.Int64
+	parts atomic.Int64
+	bytes atomic.Int64
+}
+
+func (u *diskUsage) add(files, parts, bytes int64) {
+	u.files.Add(files)
+	u.parts.Add(parts)
+	u.bytes.Add(bytes)
+}
+
+// dataDirFor возвращает состояние каталога данных; при первом обращении счётчики
+// заполняются однократным обходом каталога.
+func dataDirFor(root string) *dataDir {
+	key := filepath.Clean(root)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	d, ok := registry[key]
+	if !ok {
+		d = &dataDir{
+			root:  key,
+			locks: &fileLocks{root: key, held: map[string]*fileLock{}},
+			usage: scanUsage(key),
+		}
+		registry[key] = d
+	}
+
+	return d
+}
+
+// scanUsage считает каталоги файлов, части и их объём.
+func scanUsage(root string) *diskUsage {
+	u := &diskUsage{}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return u
+	}
+
+	for _, e := range entries {
+		if !isFileDir(e) {
+			continue
+		}
+		parts, bytes := partsUsage(filepath.Join(root, e.Name()))
+		u.add(1, parts, bytes)
+	}
+
+	return u
+}
+
+// isFileDir отличает каталоги файлов от служебных скрытых каталогов вроде .locks.
+func isFileDir(e os.DirEntry) bool {
+	return e.IsDir() && !strings.HasPrefix(e.Name(), ".")
+}
+
+// partsUsage возвращает число и суммарный размер частей в каталоге файла.
+func partsUsage(dir string) (int64, int64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0
+	}
+
+	var parts, bytes int64
+	for _, e := range entries {
+		if e.IsDir() || !isPartFile(e.Name()) {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			parts++
+			bytes += info.Size()
+		}
+	}
+
+	return parts, bytes
+}
+
+// isPartFile сообщает, что имя — имя файла части, а не meta.json или временный файл.
+func isPartFile(name string) bool {
+	var idx int
+	_, err := fmt.Sscanf(name, partFilenameFormat, &idx)
+
+	return err == nil && fmt.Sprintf(partFilenameFormat, idx) == name
+}
+
+// partSize возвращает размер записанной части и признак её наличия.
+func partSize(path string) (int64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+
+	return info.Size(), true
+}
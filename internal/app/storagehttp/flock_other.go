@@ -0,0 +1,14 @@
+//go:build !unix
+
+package storagehttp
+
+import (
+	"errors"
+	"os"
+)
+
+const flockSupported = false
+
+func flockFile(*os.File) error {
+	return errors.ErrUnsupported
+}
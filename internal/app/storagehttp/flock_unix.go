@@ -0,0 +1,20 @@
+//go:build unix
+
+package storagehttp
+
+import (
+	"os"
+	"syscall"
+)
+
+const flockSupported = true
+
+// flockFile берёт эксклюзивный flock на файл, ожидая освобождения.
+func flockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package storagehttp
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sir_venger/s3_lite/pkg/storageproto"
+)
+
+// finalizeFile выставляет в meta.json итоговое число частей и помечает загрузку завершённой:
+// после этого GC не удаляет каталог, сколько бы частей файла ни лежало на узле.
+func (a *Server) finalizeFile(w http.ResponseWriter, r *http.Request) {
+	fileID := chi.URLParam(r, "fileID")
+	if fileID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	total, err := strconv.Atoi(r.Header.Get(storageproto.HeaderTotalParts))
+	if err != nil || total <= 0 {
+		http.Error(w, "invalid total parts header", http.StatusBadRequest)
+		return
+	}
+
+	unlock, ok := a.lockFile(w, fileID)
+	if !ok {
+		return
+	}
+	err = setMetaTotal(filepath.Join(a.dataDir, fileID, metaFileName), total)
+	unlock()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.NotFound(w, r)
+	case errors.Is(err, errTotalTooSmall):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -44,7 +44,7 @@ func StartGC(root string, ttl time.Duration, every time.Duration) func() {
 	}
 }
 
-// SweepOnce удаляет каталоги, у которых meta.json устарел и содержит неполные данные
+// SweepOnce удаляет каталоги, у которых meta.json устарел, а загрузка не была завершена
 func SweepOnce(root string, ttl time.Duration) error {
 	now := time.Now()
 	entries, err := os.ReadDir(root)
@@ -52,31 +52,75 @@ func SweepOnce(root string, ttl time.Duration) error {
 		return err
 	}
 
+	d := dataDirFor(root)
 	for _, e := range entries {
-		if !e.IsDir() {
+		if !isFileDir(e) {
 			continue
 		}
 
-		pdir := filepath.Join(root, e.Name())
-		metaPath := filepath.Join(pdir, metaFileName)
-		fi, err := os.Stat(metaPath)
+		unlock, err := d.locks.lock(e.Name())
 		if err != nil {
-			continue
+			return err
 		}
+		d.sweepDir(filepath.Join(root, e.Name()), now, ttl)
+		unlock()
+	}
+
+	return nil
+}
+
+// sweepTempFiles удаляет временные файлы, брошенные оборванными записями.
+func sweepTempFiles(dir string, now time.Time, ttl time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
 
-		if now.Sub(fi.ModTime()) < ttl {
+	for _, e := range entries {
+		if e.IsDir() || !isTempFile(e.Name()) {
 			continue
 		}
-
-		fm, err := readMeta(metaPath)
-		if err != nil {
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < ttl {
 			continue
 		}
+		_ = os.Remove(filepath.Join(dir, e.Name()))
+	}
+}
+
+// sweepDir удаляет каталог файла, если его загрузка не завершилась за ttl. Вызывается под блокировкой файла.
+func (d *dataDir) sweepDir(pdir string, now time.Time, ttl time.Duration) {
+	sweepTempFiles(pdir, now, ttl)
 
-		if len(fm.Parts) < fm.TotalParts {
-			_ = os.RemoveAll(pdir)
+	metaPath := filepath.Join(pdir, metaFileName)
+	fi, err := os.Stat(metaPath)
+	if err != nil {
+		// Пустой каталог без meta.json остаётся, если процесс упал до записи первой части.
+		if info, err := os.Stat(pdir); err == nil && now.Sub(info.ModTime()) >= ttl && os.Remove(pdir) == nil {
+			d.usage.add(-1, 0, 0)
 		}
+		return
 	}
 
-	return nil
+	if now.Sub(fi.ModTime()) < ttl {
+		return
+	}
+
+	fm, err := readMeta(metaPath)
+	if err != nil {
+		return
+	}
+
+	// Узел хранит лишь свою долю частей файла (реплики, шарды), поэтому полнота определяется
+	// флагом finalized, а не числом частей. Без флага (каталоги прежних версий) — по TotalParts;
+	// TotalParts == 0 — потоковая загрузка так и не была финализирована.
+	if fm.Finalized {
+		return
+	}
+	if fm.TotalParts <= 0 || len(fm.Parts) < fm.TotalParts {
+		parts, bytes := partsUsage(pdir)
+		if os.RemoveAll(pdir) == nil {
+			d.usage.add(-1, -parts, -bytes)
+		}
+	}
 }
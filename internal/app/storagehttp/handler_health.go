@@ -3,39 +3,26 @@ package storagehttp
 import (
 	"encoding/json"
 	"errors"
-	"io/fs"
 	"net/http"
-	"path/filepath"
 )
 
 type healthStats struct {
-	OK         bool  `json:"ok"`
+	OK bool `json:"ok"`
+	// FreeBytes и TotalBytes — свободное и полное место файловой системы каталога данных
+	// (0, если платформа не умеет их определять).
 	FreeBytes  int64 `json:"free_bytes"`
 	TotalBytes int64 `json:"total_bytes"`
+	// UsedBytes — суммарный размер частей на узле.
+	UsedBytes int64 `json:"used_bytes"`
+	Parts     int64 `json:"parts"`
+	Files     int64 `json:"files"`
 }
 
-// health возвращает агрегированную статистику по данным стоража.
+// health возвращает ёмкость диска и занятое частями место. Счётчики частей ведутся
+// при записи и удалении, так что проба не обходит каталог.
 func (a *Server) health(w http.ResponseWriter, r *http.Request) {
-	var total int64
-	err := filepath.WalkDir(a.dataDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if d.IsDir() {
-			return nil
-		}
-
-		info, err := d.Info()
-		if err != nil {
-			return err
-		}
-		total += info.Size()
-
-		return nil
-	})
-
-	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+	free, total, err := diskCapacity(a.dataDir)
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -43,7 +30,11 @@ func (a *Server) health(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(healthStats{
 		OK:         true,
+		FreeBytes:  free,
 		TotalBytes: total,
+		UsedBytes:  a.usage.bytes.Load(),
+		Parts:      a.usage.parts.Load(),
+		Files:      a.usage.files.Load(),
 	})
 
 	if err != nil {
@@ -0,0 +1,57 @@
+package storagehttp
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+)
+
+// deletePart обрабатывает DELETE-запросы: удаляет часть и её запись в meta.json.
+// Когда в каталоге не остаётся частей, удаляются meta.json и пустой каталог файла.
+func (a *Server) deletePart(w http.ResponseWriter, r *http.Request) {
+	req, ok := a.requirePartRequest(w, r)
+	if !ok {
+		return
+	}
+
+	unlock, ok := a.lockFile(w, req.fileID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	size, _ := partSize(req.part)
+	partErr := os.Remove(req.part)
+	if partErr != nil && !errors.Is(partErr, fs.ErrNotExist) {
+		http.Error(w, partErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	if partErr == nil {
+		a.usage.add(0, -1, -size)
+	}
+
+	remaining, known, err := removeMetaPart(req.meta, req.idx)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if errors.Is(partErr, fs.ErrNotExist) && !known {
+		http.NotFound(w, r)
+		return
+	}
+
+	if remaining == 0 {
+		if err = os.Remove(req.meta); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Каталог остаётся, если в нём временный файл параллельной записи: она заведёт meta.json заново.
+		if os.Remove(req.dir) == nil {
+			a.usage.add(-1, 0, 0)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,7 +1,6 @@
 package storagehttp
 
 import (
-	"io"
 	"net/http"
 	"os"
 	"strconv"
@@ -10,6 +9,7 @@ import (
 )
 
 // fetchPart обслуживает GET-запросы, возвращая содержимое части.
+// Поддерживает Range/If-Range, так что REST может забирать только нужный кусок части.
 func (a *Server) fetchPart(w http.ResponseWriter, r *http.Request) {
 	req, ok := a.requirePartRequest(w, r)
 	if !ok {
@@ -28,14 +28,15 @@ func (a *Server) fetchPart(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	size := info.Size()
-	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
-	w.Header().Set(storageproto.HeaderPartSize, strconv.FormatInt(size, 10))
-	w.Header().Set("Content-Type", "application/octet-stream")
 
-	if _, err = io.Copy(w, f); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	w.Header().Set(storageproto.HeaderPartSize, strconv.FormatInt(info.Size(), 10))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if fm, err := readMeta(req.meta); err == nil {
+		if part, ok := fm.Parts[req.idx]; ok && part.Sha256 != "" {
+			w.Header().Set("ETag", `"`+part.Sha256+`"`)
+		}
 	}
 
-	return
+	// ServeContent сам выставляет Accept-Ranges, Content-Length и Content-Range и отвечает 206/416.
+	http.ServeContent(w, r, "", info.ModTime(), f)
 }
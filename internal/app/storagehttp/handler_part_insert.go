@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 
 	"github.com/sir_venger/s3_lite/pkg/storageproto"
@@ -21,12 +22,10 @@ func (a *Server) insertPart(w http.ResponseWriter, r *http.Request) {
 	a.writePart(w, r, req)
 }
 
+// writePart пишет часть во временный файл и переносит её на место только после
+// fsync и проверки размера и sha256, так что оборванный или испорченный запрос
+// не оставляет на диске битую часть.
 func (a *Server) writePart(w http.ResponseWriter, r *http.Request, req *partRequest) {
-	if err := os.MkdirAll(req.dir, 0o755); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	expSha := r.Header.Get(storageproto.HeaderChecksum)
 	size, err := parseContentLength(r.Header.Get("Content-Length"))
 	if err != nil {
@@ -34,38 +33,62 @@ func (a *Server) writePart(w http.ResponseWriter, r *http.Request, req *partRequ
 		return
 	}
 
-	f, err := os.Create(req.part)
+	// 0 — итоговое число частей пока неизвестно (потоковая загрузка), его выставит finalize.
+	totalParts, err := strconv.Atoi(r.Header.Get(storageproto.HeaderTotalParts))
+	if err != nil || totalParts < 0 {
+		http.Error(w, "invalid total parts header", http.StatusBadRequest)
+		return
+	}
+
+	// Каталог и временный файл создаются под блокировкой, чтобы удаление последней части
+	// или GC не убрали каталог между ними. Тело читается без блокировки: части одного
+	// файла пишутся параллельно.
+	unlock, ok := a.lockFile(w, req.fileID)
+	if !ok {
+		return
+	}
+	f, err := a.createPartTemp(req)
+	unlock()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer f.Close()
 
 	h := sha256.New()
-	wrt := io.MultiWriter(f, h)
-	n, err := io.Copy(wrt, r.Body)
+	n, err := io.Copy(io.MultiWriter(f, h), r.Body)
 	if err != nil {
+		discardTemp(f)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	if size > 0 && n != size {
+		discardTemp(f)
 		http.Error(w, "size mismatch", http.StatusBadRequest)
 		return
 	}
 	got := hex.EncodeToString(h.Sum(nil))
 	if expSha != "" && got != expSha {
+		discardTemp(f)
 		http.Error(w, "sha256 mismatch", http.StatusConflict)
 		return
 	}
 
-	totalPartsStr := r.Header.Get(storageproto.HeaderTotalParts)
-	totalParts, err := strconv.Atoi(totalPartsStr)
-	if err != nil || totalParts <= 0 {
-		http.Error(w, "invalid total parts header", http.StatusBadRequest)
+	if unlock, ok = a.lockFile(w, req.fileID); !ok {
+		discardTemp(f)
 		return
 	}
-
-	if err = writeMeta(req.meta, req.fileID, req.idx, n, got, totalParts); err != nil {
+	oldSize, replaced := partSize(req.part)
+	err = commitTemp(f, req.part)
+	if err == nil {
+		if replaced {
+			a.usage.add(0, 0, n-oldSize)
+		} else {
+			a.usage.add(0, 1, n)
+		}
+		err = writeMeta(req.meta, req.fileID, req.idx, n, got, totalParts)
+	}
+	unlock()
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -85,3 +108,28 @@ func parseContentLength(value string) (int64, error) {
 
 	return sz, nil
 }
+
+func (a *Server) createPartTemp(req *partRequest) (*os.File, error) {
+	created, err := ensureDir(req.dir)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		a.usage.add(1, 0, 0)
+	}
+
+	return createTemp(req.part)
+}
+
+// ensureDir создаёт каталог файла и синхронизирует родительский каталог,
+// чтобы новая запись каталога не потерялась при сбое. Возвращает признак того, что каталог создан.
+func ensureDir(dir string) (bool, error) {
+	if _, err := os.Stat(dir); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, err
+	}
+
+	return true, syncDir(filepath.Dir(dir))
+}
@@ -28,6 +28,7 @@ func (a *Server) inspectPart(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set(storageproto.HeaderPartSize, strconv.FormatInt(part.Size, 10))
 	w.Header().Set(storageproto.HeaderChecksum, part.Sha256)
+	w.Header().Set("Accept-Ranges", "bytes")
 	w.WriteHeader(http.StatusOK)
 
 	return
@@ -0,0 +1,98 @@
+package storagehttp
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// locksDirName — каталог с файлами межпроцессных блокировок внутри data dir.
+	locksDirName = ".locks"
+	// lockStripes — число файлов блокировок: fileID хешируется в один из них,
+	// чтобы файлов не становилось больше с каждым загруженным файлом.
+	lockStripes = 256
+)
+
+// fileLocks сериализует изменения каталога одного файла: запись и удаление частей,
+// обновление meta.json и GC. Блокировки общие для всех обработчиков и GC одного data dir.
+type fileLocks struct {
+	root  string
+	flock atomic.Bool
+
+	mu   sync.Mutex
+	held map[string]*fileLock
+}
+
+type fileLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// UseFlock включает для каталога данных межпроцессные блокировки через flock(2) —
+// на случай, если с одним каталогом работают несколько процессов (например, отдельный GC).
+func UseFlock(root string) error {
+	if !flockSupported {
+		return fmt.Errorf("flock is not supported on this platform")
+	}
+	l := dataDirFor(root).locks
+	if err := os.MkdirAll(filepath.Join(l.root, locksDirName), 0o755); err != nil {
+		return err
+	}
+	l.flock.Store(true)
+
+	return nil
+}
+
+// lock захватывает блокировку файла и возвращает функцию её освобождения.
+func (l *fileLocks) lock(fileID string) (func(), error) {
+	l.mu.Lock()
+	fl, ok := l.held[fileID]
+	if !ok {
+		fl = &fileLock{}
+		l.held[fileID] = fl
+	}
+	fl.refs++
+	l.mu.Unlock()
+
+	fl.mu.Lock()
+	release := func() {
+		fl.mu.Unlock()
+		l.mu.Lock()
+		fl.refs--
+		if fl.refs == 0 {
+			delete(l.held, fileID)
+		}
+		l.mu.Unlock()
+	}
+	if !l.flock.Load() {
+		return release, nil
+	}
+
+	f, err := os.OpenFile(l.stripePath(fileID), os.O_CREATE|os.O_RDWR, 0o644)
+	if err == nil {
+		if err = flockFile(f); err != nil {
+			_ = f.Close()
+		}
+	}
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("lock %s: %w", fileID, err)
+	}
+
+	return func() {
+		// Закрытие дескриптора снимает flock.
+		_ = f.Close()
+		release()
+	}, nil
+}
+
+func (l *fileLocks) stripePath(fileID string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fileID))
+
+	return filepath.Join(l.root, locksDirName, fmt.Sprintf("%02x.lock", h.Sum32()%lockStripes))
+}
@@ -2,9 +2,12 @@ package storagehttp
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 )
 
+var errTotalTooSmall = errors.New("total parts is less than stored part index")
+
 type partMeta struct {
 	Index  int    `json:"index"`
 	Size   int64  `json:"size"`
@@ -15,9 +18,13 @@ type fileMeta struct {
 	FileID     string           `json:"file_id"`
 	TotalParts int              `json:"total_parts"`
 	Parts      map[int]partMeta `json:"parts"`
+	// Finalized — загрузка файла завершена: узел хранит свою долю частей, и GC каталог не трогает.
+	// Выставляется finalize либо записью, после которой на узле лежат все TotalParts частей.
+	Finalized bool `json:"finalized,omitempty"`
 }
 
-// writeMeta обновляет метаданные файла на диске.
+// writeMeta обновляет метаданные файла на диске. Файл заменяется целиком через rename,
+// поэтому при сбое на диске остаётся прежняя или новая версия, но не обрезанная.
 func writeMeta(path string, fileID string, idx int, size int64, sha string, total int) error {
 	fm := fileMeta{
 		FileID:     fileID,
@@ -30,6 +37,9 @@ func writeMeta(path string, fileID string, idx int, size int64, sha string, tota
 		if err != nil {
 			return err
 		}
+		if fm.TotalParts <= 0 && total > 0 {
+			fm.TotalParts = total
+		}
 	}
 
 	fm.Parts[idx] = partMeta{
@@ -37,13 +47,64 @@ func writeMeta(path string, fileID string, idx int, size int64, sha string, tota
 		Size:   size,
 		Sha256: sha,
 	}
+	if fm.TotalParts > 0 && len(fm.Parts) >= fm.TotalParts {
+		fm.Finalized = true
+	}
+
+	b, err := json.MarshalIndent(fm, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, b)
+}
+
+// setMetaTotal фиксирует итоговое число частей файла и помечает загрузку завершённой.
+// Возвращает errTotalTooSmall, если на узле уже есть часть с индексом за пределами total.
+func setMetaTotal(path string, total int) error {
+	fm, err := readMeta(path)
+	if err != nil {
+		return err
+	}
+
+	for idx := range fm.Parts {
+		if idx >= total {
+			return errTotalTooSmall
+		}
+	}
+	fm.TotalParts = total
+	fm.Finalized = true
 
 	b, err := json.MarshalIndent(fm, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, b, 0o644)
+	return writeFileAtomic(path, b)
+}
+
+// removeMetaPart убирает часть из метаданных и возвращает число оставшихся частей
+// и признак того, что часть была в них записана. Части удаляются вместе с файлом,
+// поэтому флаг finalized снимается: остаток каталога, если удаление оборвётся, уберёт GC.
+func removeMetaPart(path string, idx int) (int, bool, error) {
+	fm, err := readMeta(path)
+	if err != nil {
+		return 0, false, err
+	}
+
+	_, known := fm.Parts[idx]
+	if !known {
+		return len(fm.Parts), false, nil
+	}
+	delete(fm.Parts, idx)
+	fm.Finalized = false
+
+	b, err := json.MarshalIndent(fm, "", "  ")
+	if err != nil {
+		return 0, true, err
+	}
+
+	return len(fm.Parts), true, writeFileAtomic(path, b)
 }
 
 // readMeta читает метаданные файла с диска.
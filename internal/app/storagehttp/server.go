@@ -9,12 +9,19 @@ import (
 // Server serves the storage node HTTP API on top of the local filesystem.
 type Server struct {
 	dataDir string
+	// locks сериализуют изменения каталога одного файла: части одного файла могут приходить параллельно.
+	locks *fileLocks
+	// usage — занятое частями место, которое отдаёт /health.
+	usage *diskUsage
 }
 
 // New создаёт HTTP-обработчик стоража поверх каталога с данными.
 func New(dataDir string) http.Handler {
+	d := dataDirFor(dataDir)
 	srv := &Server{
 		dataDir: dataDir,
+		locks:   d.locks,
+		usage:   d.usage,
 	}
 
 	return srv.routes()
@@ -24,11 +31,13 @@ func New(dataDir string) http.Handler {
 func (a *Server) routes() http.Handler {
 	r := chi.NewRouter()
 
+	r.Post("/parts/{fileID}/finalize", a.finalizeFile)
 	r.Route("/parts/{fileID}/{idx}", func(pr chi.Router) {
 		// Accept both PUT and POST to stay compatible with the documented API and older clients.
 		pr.Put("/", a.insertPart)
 		pr.Get("/", a.fetchPart)
 		pr.Head("/", a.inspectPart)
+		pr.Delete("/", a.deletePart)
 	})
 
 	r.Get("/health", a.health)
@@ -36,3 +45,14 @@ func (a *Server) routes() http.Handler {
 
 	return r
 }
+
+// lockFile захватывает блокировку каталога файла; при ошибке отвечает 500.
+func (a *Server) lockFile(w http.ResponseWriter, fileID string) (func(), bool) {
+	unlock, err := a.locks.lock(fileID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return unlock, true
+}
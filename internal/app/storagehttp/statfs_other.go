@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package storagehttp
+
+import "errors"
+
+func diskCapacity(string) (int64, int64, error) {
+	return 0, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,16 @@
+//go:build linux || darwin
+
+package storagehttp
+
+import "syscall"
+
+// diskCapacity возвращает свободное (доступное непривилегированному процессу) и полное место
+// файловой системы, на которой лежит каталог.
+func diskCapacity(dir string) (free, total int64, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+
+	return int64(st.Bavail) * int64(st.Bsize), int64(st.Blocks) * int64(st.Bsize), nil
+}
@@ -0,0 +1,181 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestAPIKeys_Permissions(t *testing.T) {
+	node := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(node.Close)
+
+	const adminKey = "bootstrap-admin-key"
+	cfg := &config.Config{
+		ListenAddr:  ":0",
+		MetaDSN:     fmt.Sprintf("memory://%s-%d", t.Name(), time.Now().UnixNano()),
+		Storages:    []string{node.URL},
+		AuthEnabled: true,
+		AdminAPIKey: adminKey,
+	}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	do := func(key, method, path string, body []byte) (int, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(method, restSrv.URL+path, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, data
+	}
+	issue := func(perms string) string {
+		t.Helper()
+		status, body := do(adminKey, http.MethodPost, "/admin/keys", []byte(`{"name":"test","permissions":`+perms+`}`))
+		if status != http.StatusCreated {
+			t.Fatalf("create key %s: %d %s", perms, status, body)
+		}
+		var resp struct {
+			Key string `json:"key"`
+		}
+		_ = json.Unmarshal(body, &resp)
+		return resp.Key
+	}
+
+	if status, _ := do("", http.MethodGet, "/files", nil); status != http.StatusUnauthorized {
+		t.Fatalf("anonymous list: %d", status)
+	}
+	if status, _ := do("nope.nope", http.MethodGet, "/files", nil); status != http.StatusUnauthorized {
+		t.Fatalf("unknown key: %d", status)
+	}
+	if status, _ := do(adminKey, http.MethodPost, "/admin/keys", []byte(`{"permissions":["root"]}`)); status != http.StatusBadRequest {
+		t.Fatalf("unknown permission: %d", status)
+	}
+
+	reader, writer := issue(`["read"]`), issue(`["read","write"]`)
+
+	status, body := do(writer, http.MethodPost, "/files", []byte("payload"))
+	if status != http.StatusOK {
+		t.Fatalf("writer upload: %d %s", status, body)
+	}
+	var res uploadResponse
+	_ = json.Unmarshal(body, &res)
+
+	if status, body = do(reader, http.MethodGet, "/files/"+res.FileID, nil); status != http.StatusOK || string(body) != "payload" {
+		t.Fatalf("reader get: %d %q", status, body)
+	}
+	if status, _ = do(reader, http.MethodPost, "/files", []byte("x")); status != http.StatusForbidden {
+		t.Fatalf("reader upload: %d", status)
+	}
+	if status, _ = do(writer, http.MethodDelete, "/files/"+res.FileID, nil); status != http.StatusForbidden {
+		t.Fatalf("writer delete: %d", status)
+	}
+	if status, _ = do(writer, http.MethodGet, "/admin/config", nil); status != http.StatusForbidden {
+		t.Fatalf("writer admin config: %d", status)
+	}
+	if status, _ = do(writer, http.MethodPost, "/admin/storages", []byte(`{"storages":["http://evil"]}`)); status != http.StatusForbidden {
+		t.Fatalf("writer add storages: %d", status)
+	}
+
+	// Ключи создаёт и читает только admin; секреты в списке не видны.
+	admin := issue(`["admin"]`)
+	status, body = do(admin, http.MethodGet, "/admin/keys", nil)
+	if status != http.StatusOK || strings.Contains(string(body), `"key"`) || strings.Contains(string(body), "secret") {
+		t.Fatalf("list keys: %d %s", status, body)
+	}
+	if status, body = do(admin, http.MethodGet, "/admin/config", nil); status != http.StatusOK || strings.Contains(string(body), adminKey) {
+		t.Fatalf("admin config: %d %s", status, body)
+	}
+	if status, _ = do(admin, http.MethodDelete, "/files/"+res.FileID, nil); status != http.StatusNoContent {
+		t.Fatalf("admin delete: %d", status)
+	}
+
+	// Отозванный ключ перестаёт работать.
+	id, _, _ := strings.Cut(reader, ".")
+	if status, _ = do(admin, http.MethodDelete, "/admin/keys/"+id, nil); status != http.StatusNoContent {
+		t.Fatalf("revoke key: %d", status)
+	}
+	if status, _ = do(reader, http.MethodGet, "/files", nil); status != http.StatusUnauthorized {
+		t.Fatalf("revoked key: %d", status)
+	}
+}
+
+func TestAPIKeys_AdminClosedWithoutAuth(t *testing.T) {
+	node := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(node.Close)
+
+	// Проверка ключей выключена: данные открыты, а /admin/* — нет.
+	start := func(adminKey string) string {
+		t.Helper()
+		cfg := &config.Config{
+			ListenAddr:  ":0",
+			MetaDSN:     fmt.Sprintf("memory://%s-%d", t.Name(), time.Now().UnixNano()),
+			Storages:    []string{node.URL},
+			AdminAPIKey: adminKey,
+		}
+		handler, srv, err := resthttp.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("new rest server: %v", err)
+		}
+		t.Cleanup(srv.Close)
+		restSrv := httptest.NewServer(handler)
+		t.Cleanup(restSrv.Close)
+		return restSrv.URL
+	}
+	status := func(base, key, method, path string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, base+path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	open := start("")
+	if code := status(open, "", http.MethodGet, "/files"); code != http.StatusOK {
+		t.Fatalf("anonymous list without auth: %d", code)
+	}
+	for _, path := range []string{"/admin/config", "/admin/storages", "/admin/keys"} {
+		if code := status(open, "", http.MethodGet, path); code != http.StatusUnauthorized {
+			t.Fatalf("anonymous %s without admin key: %d, want 401", path, code)
+		}
+	}
+
+	const adminKey = "admin-only-key"
+	guarded := start(adminKey)
+	if code := status(guarded, "", http.MethodGet, "/admin/config"); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous admin config: %d, want 401", code)
+	}
+	if code := status(guarded, adminKey, http.MethodGet, "/admin/config"); code != http.StatusOK {
+		t.Fatalf("admin config with admin key: %d", code)
+	}
+}
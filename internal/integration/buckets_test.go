@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestBuckets_UniqueKeysAndOverwrite(t *testing.T) {
+	dsns := map[string]string{
+		"memory": fmt.Sprintf("memory://%s-%d", t.Name(), time.Now().UnixNano()),
+		"bolt":   "bolt://" + filepath.Join(t.TempDir(), "meta.db"),
+	}
+
+	for backend, dsn := range dsns {
+		t.Run(backend, func(t *testing.T) {
+			node := httptest.NewServer(storagehttp.New(t.TempDir()))
+			t.Cleanup(node.Close)
+
+			cfg := &config.Config{ListenAddr: ":0", MetaDSN: dsn, Storages: []string{node.URL}}
+			handler, srv, err := resthttp.NewServer(cfg)
+			if err != nil {
+				t.Fatalf("new rest server: %v", err)
+			}
+			t.Cleanup(srv.Close)
+			restSrv := httptest.NewServer(handler)
+			t.Cleanup(restSrv.Close)
+
+			do := func(method, path string, body []byte) (int, []byte) {
+				t.Helper()
+				req, err := http.NewRequest(method, restSrv.URL+path, bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatalf("%s %s: %v", method, path, err)
+				}
+				defer resp.Body.Close()
+				data, _ := io.ReadAll(resp.Body)
+				return resp.StatusCode, data
+			}
+
+			if status, _ := do(http.MethodPost, "/buckets", []byte(`{"name":"docs"}`)); status != http.StatusCreated {
+				t.Fatalf("create bucket: %d", status)
+			}
+			if status, _ := do(http.MethodPost, "/buckets", []byte(`{"name":"docs"}`)); status != http.StatusConflict {
+				t.Fatalf("duplicate bucket: %d", status)
+			}
+			if status, _ := do(http.MethodPost, "/buckets", []byte(`{"name":"Bad_Name"}`)); status != http.StatusBadRequest {
+				t.Fatalf("invalid bucket name: %d", status)
+			}
+			if status, _ := do(http.MethodPut, "/buckets/missing/objects/a.txt", []byte("x")); status != http.StatusNotFound {
+				t.Fatalf("put into missing bucket: %d", status)
+			}
+
+			// Ключ с пробелом и слешами адресуется экранированным путём.
+			const keyPath = "/buckets/docs/objects/reports/2024/q1%20final.txt"
+			status, body := do(http.MethodPut, keyPath, []byte("first version"))
+			if status != http.StatusOK {
+				t.Fatalf("put object: %d %s", status, body)
+			}
+			var first uploadResponse
+			if err = json.Unmarshal(body, &first); err != nil {
+				t.Fatal(err)
+			}
+
+			// Перезапись ключа заменяет объект и удаляет прежний файл.
+			if status, body = do(http.MethodPut, keyPath, []byte("second version")); status != http.StatusOK {
+				t.Fatalf("overwrite object: %d %s", status, body)
+			}
+			if status, body = do(http.MethodGet, keyPath, nil); status != http.StatusOK || string(body) != "second version" {
+				t.Fatalf("get object: %d %q", status, body)
+			}
+			if status, _ = do(http.MethodGet, "/files/"+first.FileID, nil); status != http.StatusNotFound {
+				t.Fatalf("replaced file is still readable: %d", status)
+			}
+
+			// Составная загрузка с адресом объекта тоже заменяет ключ.
+			status, body = do(http.MethodPost, "/uploads?bucket=docs&key=reports/2024/q1%20final.txt", nil)
+			if status != http.StatusCreated {
+				t.Fatalf("create upload: %d %s", status, body)
+			}
+			var upload struct {
+				UploadID string `json:"upload_id"`
+			}
+			_ = json.Unmarshal(body, &upload)
+			if status, _ = do(http.MethodPut, "/uploads/"+upload.UploadID+"/parts/1", []byte("third version")); status != http.StatusOK {
+				t.Fatalf("upload part: %d", status)
+			}
+			if status, _ = do(http.MethodPost, "/uploads/"+upload.UploadID+"/complete", nil); status != http.StatusOK {
+				t.Fatalf("complete upload: %d", status)
+			}
+			if status, body = do(http.MethodGet, keyPath, nil); status != http.StatusOK || string(body) != "third version" {
+				t.Fatalf("get multipart object: %d %q", status, body)
+			}
+
+			if status, _ = do(http.MethodPut, "/buckets/docs/objects/readme.md", []byte("readme")); status != http.StatusOK {
+				t.Fatalf("put readme: %d", status)
+			}
+			status, body = do(http.MethodGet, "/buckets/docs/objects?sort=name", nil)
+			if status != http.StatusOK {
+				t.Fatalf("list objects: %d", status)
+			}
+			var page struct {
+				Files []struct {
+					Key  string `json:"key"`
+					Size int64  `json:"size"`
+				} `json:"files"`
+			}
+			if err = json.Unmarshal(body, &page); err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for _, f := range page.Files {
+				keys = append(keys, f.Key)
+			}
+			if got := strings.Join(keys, ","); got != "readme.md,reports/2024/q1 final.txt" {
+				t.Fatalf("objects = %s", got)
+			}
+
+			if status, _ = do(http.MethodDelete, "/buckets/docs", nil); status != http.StatusConflict {
+				t.Fatalf("delete non-empty bucket: %d", status)
+			}
+			for _, p := range []string{keyPath, "/buckets/docs/objects/readme.md"} {
+				if status, _ = do(http.MethodDelete, p, nil); status != http.StatusNoContent {
+					t.Fatalf("delete %s: %d", p, status)
+				}
+			}
+			if status, _ = do(http.MethodHead, keyPath, nil); status != http.StatusNotFound {
+				t.Fatalf("head deleted object: %d", status)
+			}
+			if status, _ = do(http.MethodDelete, "/buckets/docs", nil); status != http.StatusNoContent {
+				t.Fatalf("delete bucket: %d", status)
+			}
+			if status, body = do(http.MethodGet, "/buckets", nil); status != http.StatusOK || !strings.Contains(string(body), `"buckets":[]`) {
+				t.Fatalf("list buckets: %d %s", status, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestStream_VerifiesPartChecksums(t *testing.T) {
+	dataDir := t.TempDir()
+	node := httptest.NewServer(storagehttp.New(dataDir))
+	t.Cleanup(node.Close)
+
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{node.URL}}
+	handler, _, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := bytes.Repeat([]byte("checksum"), 750) // 6000 байт, 6 частей по 1000
+	upload := func() string {
+		res, err := uploadFile(restSrv.URL+"/files", payload)
+		if err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		return res.FileID
+	}
+	corrupt := func(fileID string, idx int) {
+		path := filepath.Join(dataDir, fileID, fmt.Sprintf("%d.part", idx))
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b[len(b)/2] ^= 0xFF
+		if err = os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("trailer digest", func(t *testing.T) {
+		fileID := upload()
+		req, _ := http.NewRequest(http.MethodGet, restSrv.URL+"/files/"+fileID, nil)
+		req.Header.Set("TE", "trailers")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil || !bytes.Equal(got, payload) {
+			t.Fatalf("download failed: %v", err)
+		}
+		want := sha256.Sum256(payload)
+		if resp.Trailer.Get("X-Checksum-Sha256") != hex.EncodeToString(want[:]) {
+			t.Fatalf("trailer digest %q", resp.Trailer.Get("X-Checksum-Sha256"))
+		}
+	})
+
+	t.Run("corrupted first part", func(t *testing.T) {
+		fileID := upload()
+		corrupt(fileID, 0)
+
+		resp, err := http.Get(restSrv.URL + "/files/" + fileID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Fatalf("status %s, want 502", resp.Status)
+		}
+	})
+
+	t.Run("corrupted part under range", func(t *testing.T) {
+		fileID := upload()
+		corrupt(fileID, 2)
+
+		// Диапазон, покрывающий часть целиком, сверяется; режущий часть читается диапазоном без сверки.
+		status, _, _ := rangeGet(t, restSrv.URL+"/files/"+fileID, "bytes=2000-2999", "")
+		if status != http.StatusBadGateway {
+			t.Fatalf("status %d, want 502", status)
+		}
+		status, got, _ := rangeGet(t, restSrv.URL+"/files/"+fileID, "bytes=2100-2200", "")
+		if status != http.StatusPartialContent || !bytes.Equal(got, payload[2100:2201]) {
+			t.Fatalf("range inside part: status %d, %q", status, got)
+		}
+	})
+
+	t.Run("corrupted later part aborts", func(t *testing.T) {
+		fileID := upload()
+		corrupt(fileID, 4)
+
+		resp, err := http.Get(restSrv.URL + "/files/" + fileID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err == nil || len(got) >= len(payload) {
+			t.Fatalf("corrupted download must be cut short, got %d bytes, err %v", len(got), err)
+		}
+	})
+}
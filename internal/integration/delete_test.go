@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestDeleteFile_RetriesOfflineStorage(t *testing.T) {
+	onlineDir := t.TempDir()
+	flakyDir := t.TempDir()
+
+	online := httptest.NewServer(storagehttp.New(onlineDir))
+	t.Cleanup(online.Close)
+
+	var offline atomic.Bool
+	flakyHandler := storagehttp.New(flakyDir)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if offline.Load() {
+			http.Error(w, "offline", http.StatusServiceUnavailable)
+			return
+		}
+		flakyHandler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(flaky.Close)
+
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{online.URL, flaky.URL}}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	res, err := uploadFile(restSrv.URL+"/files", bytes.Repeat([]byte("del"), 10000))
+	if err != nil {
+		t.Fatalf("upload file: %v", err)
+	}
+
+	offline.Store(true)
+	req, _ := http.NewRequest(http.MethodDelete, restSrv.URL+"/files/"+res.FileID, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status %s", resp.Status)
+	}
+
+	resp, err = http.Get(restSrv.URL + "/files/" + res.FileID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted file still readable: %s", resp.Status)
+	}
+
+	assertNoFileDir(t, onlineDir, res.FileID)
+	if _, err = os.Stat(filepath.Join(flakyDir, res.FileID)); err != nil {
+		t.Fatalf("offline storage data should still exist before retry: %v", err)
+	}
+
+	offline.Store(false)
+	done, err := srv.FilesService.RetryPendingDeletions(context.Background())
+	if err != nil {
+		t.Fatalf("retry pending deletions: %v", err)
+	}
+	if done == 0 {
+		t.Fatalf("no pending deletions were retried")
+	}
+	assertNoFileDir(t, flakyDir, res.FileID)
+}
+
+func assertNoFileDir(t *testing.T, root, fileID string) {
+	t.Helper()
+	if _, err := os.Stat(filepath.Join(root, fileID)); !os.IsNotExist(err) {
+		t.Fatalf("file dir %s still exists in %s (err=%v)", fileID, root, err)
+	}
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestStream_PrefetchesPartsConcurrently(t *testing.T) {
+	var (
+		slowGets    atomic.Bool
+		inFlight    atomic.Int32
+		maxInFlight atomic.Int32
+		storages    []string
+	)
+	for i := 0; i < 3; i++ {
+		node := storagehttp.New(t.TempDir())
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet && slowGets.Load() {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					m := maxInFlight.Load()
+					if n <= m || maxInFlight.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(30 * time.Millisecond)
+			}
+			node.ServeHTTP(w, r)
+		}))
+		t.Cleanup(s.Close)
+		storages = append(storages, s.URL)
+	}
+
+	cfg := &config.Config{
+		ListenAddr:          ":0",
+		MetaDSN:             "memory://" + t.Name(),
+		Storages:            storages,
+		DownloadPrefetch:    3,
+		DownloadBufferBytes: 1, // минимальная очередь: порядок обязан сохраняться и без запаса
+	}
+	handler, _, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := make([]byte, 3<<20)
+	for i := range payload {
+		payload[i] = byte(i / 4096)
+	}
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	slowGets.Store(true)
+	got, err := downloadFile(restSrv.URL + "/files/" + res.FileID)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("downloaded data mismatch")
+	}
+	if m := maxInFlight.Load(); m < 2 || m > 3 {
+		t.Fatalf("max concurrent part fetches %d, want 2..3", m)
+	}
+}
@@ -0,0 +1,225 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+type erasureMetaResponse struct {
+	Erasure struct {
+		DataShards   int `json:"data_shards"`
+		ParityShards int `json:"parity_shards"`
+	} `json:"erasure"`
+	Parts []struct {
+		Index     int   `json:"index"`
+		Size      int64 `json:"size"`
+		ShardSize int64 `json:"shard_size"`
+		Shards    []struct {
+			Index   int    `json:"index"`
+			Storage string `json:"storage"`
+		} `json:"shards"`
+	} `json:"parts"`
+}
+
+func TestErasureCoding_ReconstructsLostShards(t *testing.T) {
+	dirs := make(map[string]string)
+	nodes := make(map[string]*httptest.Server)
+	var urls []string
+	for i := 0; i < 5; i++ {
+		dir := t.TempDir()
+		node := httptest.NewServer(storagehttp.New(dir))
+		t.Cleanup(node.Close)
+		urls = append(urls, node.URL)
+		dirs[node.URL] = dir
+		nodes[node.URL] = node
+	}
+
+	cfg := &config.Config{
+		ListenAddr:          ":0",
+		MetaDSN:             "memory://" + t.Name(),
+		Storages:            urls,
+		ErasureDataShards:   3,
+		ErasureParityShards: 2,
+		ErasureStripeBytes:  1000,
+	}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := make([]byte, 4500) // 5 полос, последняя неполная
+	for i := range payload {
+		payload[i] = byte(i * 7)
+	}
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Parts != 5 {
+		t.Fatalf("want 5 stripes, got %d", res.Parts)
+	}
+
+	resp, err := http.Get(restSrv.URL + "/files/" + res.FileID + "/meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta erasureMetaResponse
+	err = json.NewDecoder(resp.Body).Decode(&meta)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+	if meta.Erasure.DataShards != 3 || meta.Erasure.ParityShards != 2 {
+		t.Fatalf("unexpected layout %+v", meta.Erasure)
+	}
+	for _, p := range meta.Parts {
+		seen := make(map[string]bool)
+		for _, sh := range p.Shards {
+			seen[sh.Storage] = true
+		}
+		if len(p.Shards) != 5 || len(seen) != 5 {
+			t.Fatalf("stripe %d: shards must sit on 5 distinct nodes: %+v", p.Index, p.Shards)
+		}
+	}
+
+	// Портим шард данных полосы 0 и гасим узел с другим её шардом: теряем ровно m шардов.
+	s0 := meta.Parts[0].Shards
+	path := filepath.Join(dirs[s0[0].Storage], res.FileID, strconv.Itoa(s0[0].Index)+".part")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 0xFF
+	if err = os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nodes[s0[1].Storage].Close()
+
+	got, err := downloadFile(restSrv.URL + "/files/" + res.FileID)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("download with %d lost shards: %v", 2, err)
+	}
+
+	status, body, _ := rangeGet(t, restSrv.URL+"/files/"+res.FileID, "bytes=900-2099", "")
+	if status != http.StatusPartialContent || !bytes.Equal(body, payload[900:2100]) {
+		t.Fatalf("range over reconstructed stripes: status %d, %d bytes", status, len(body))
+	}
+
+	// Третья потеря в полосе 0 уже не восстанавливается.
+	nodes[s0[2].Storage].Close()
+	resp, err = http.Get(restSrv.URL + "/files/" + res.FileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Fatalf("download must fail with more than m lost shards")
+	}
+}
+
+func TestErasureCoding_WriteQuorum(t *testing.T) {
+	var (
+		urls   []string
+		roots  []string
+		broken = make(map[string]*atomic.Bool)
+	)
+	for i := 0; i < 5; i++ {
+		root := t.TempDir()
+		storage := storagehttp.New(root)
+		failing := new(atomic.Bool)
+		// Узел отвечает на health, но не принимает записи, пока failing выставлен.
+		node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut && failing.Load() {
+				http.Error(w, "disk is read-only", http.StatusInternalServerError)
+				return
+			}
+			storage.ServeHTTP(w, r)
+		}))
+		t.Cleanup(node.Close)
+		urls = append(urls, node.URL)
+		roots = append(roots, root)
+		broken[node.URL] = failing
+	}
+
+	cfg := &config.Config{
+		ListenAddr:          ":0",
+		MetaDSN:             "memory://" + t.Name(),
+		Storages:            urls,
+		ErasureDataShards:   3,
+		ErasureParityShards: 2,
+		ErasureStripeBytes:  1000,
+	}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := bytes.Repeat([]byte("quorum!"), 400)
+
+	// Кворум по умолчанию — k+1 = 4 шарда: один отказавший узел загрузке не мешает.
+	broken[urls[0]].Store(true)
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload with one failing node: %v", err)
+	}
+	resp, err := http.Get(restSrv.URL + "/files/" + res.FileID + "/meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta erasureMetaResponse
+	err = json.NewDecoder(resp.Body).Decode(&meta)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+	for _, p := range meta.Parts {
+		for _, sh := range p.Shards {
+			if sh.Storage == urls[0] {
+				t.Fatalf("stripe %d: failed shard is recorded on %s", p.Index, sh.Storage)
+			}
+		}
+	}
+	got, err := downloadFile(restSrv.URL + "/files/" + res.FileID)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("download with a missing shard per stripe: %v", err)
+	}
+
+	// Два отказавших узла — кворум не набран: загрузка падает, записанные шарды уходят в очередь удаления.
+	broken[urls[1]].Store(true)
+	if _, err = uploadFile(restSrv.URL+"/files", payload); err == nil {
+		t.Fatalf("upload must fail below the write quorum")
+	}
+	if _, err = srv.FilesService.RetryPendingDeletions(context.Background()); err != nil {
+		t.Fatalf("retry deletions: %v", err)
+	}
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && e.Name() != res.FileID {
+				t.Fatalf("%s: shards of the failed upload are left behind in %s", root, e.Name())
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+type fileMetaResponse struct {
+	FileID     string `json:"file_id"`
+	Name       string `json:"file_name"`
+	Size       int64  `json:"size"`
+	TotalParts int    `json:"total_parts"`
+	ETag       string `json:"etag"`
+	Parts      []struct {
+		Index    int      `json:"index"`
+		Size     int64    `json:"size"`
+		Sha256   string   `json:"sha256"`
+		Storage  string   `json:"storage"`
+		Storages []string `json:"storages"`
+	} `json:"parts"`
+}
+
+func TestHeadAndMetaEndpoints(t *testing.T) {
+	node := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(node.Close)
+
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{node.URL}}
+	handler, _, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := bytes.Repeat([]byte("meta!"), 3001)
+	req, _ := http.NewRequest(http.MethodPost, restSrv.URL+"/files", bytes.NewReader(payload))
+	req.Header.Set("X-File-Name", "report.bin")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var up uploadResponse
+	_ = json.NewDecoder(resp.Body).Decode(&up)
+	_ = resp.Body.Close()
+
+	resp, err = http.Head(restSrv.URL + "/files/" + up.FileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("HEAD status %s", resp.Status)
+	}
+	if resp.Header.Get("Content-Length") != strconv.Itoa(len(payload)) {
+		t.Fatalf("HEAD Content-Length %q", resp.Header.Get("Content-Length"))
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), `filename=report.bin`) {
+		t.Fatalf("HEAD Content-Disposition %q", resp.Header.Get("Content-Disposition"))
+	}
+	etag := resp.Header.Get("ETag")
+
+	resp, err = http.Get(restSrv.URL + "/files/" + up.FileID + "/meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta fileMetaResponse
+	err = json.NewDecoder(resp.Body).Decode(&meta)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+
+	if meta.Name != "report.bin" || meta.Size != int64(len(payload)) || meta.ETag != etag {
+		t.Fatalf("unexpected meta: %+v (HEAD etag %s)", meta, etag)
+	}
+	if len(meta.Parts) != meta.TotalParts {
+		t.Fatalf("parts listed %d, total %d", len(meta.Parts), meta.TotalParts)
+	}
+
+	var offset int64
+	for i, p := range meta.Parts {
+		if p.Index != i || p.Storage != node.URL {
+			t.Fatalf("unexpected part layout: %+v", p)
+		}
+		sum := sha256.Sum256(payload[offset : offset+p.Size])
+		if hex.EncodeToString(sum[:]) != p.Sha256 {
+			t.Fatalf("part %d sha256 mismatch", i)
+		}
+		offset += p.Size
+	}
+	if offset != int64(len(payload)) {
+		t.Fatalf("parts cover %d bytes, want %d", offset, len(payload))
+	}
+
+	resp, err = http.Head(restSrv.URL + "/files/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("HEAD missing file: %s", resp.Status)
+	}
+}
@@ -1,13 +1,19 @@
 package integration
 
 import (
+	"bytes"
+	"context"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
 	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
 )
 
 func Test_StorageGC_RemovesStaleDirs(t *testing.T) {
@@ -34,3 +40,114 @@ func Test_StorageGC_RemovesStaleDirs(t *testing.T) {
 		t.Fatalf("stale dir not removed")
 	}
 }
+
+// Узлы хранят лишь свою долю частей (шарды, реплики): после завершения загрузки GC
+// не должен удалять их каталоги, как бы стары они ни были.
+func Test_StorageGC_KeepsFinalizedMultiNodeFiles(t *testing.T) {
+	var (
+		urls  []string
+		roots []string
+	)
+	for i := 0; i < 5; i++ {
+		root := t.TempDir()
+		node := httptest.NewServer(storagehttp.New(root))
+		t.Cleanup(node.Close)
+		urls = append(urls, node.URL)
+		roots = append(roots, root)
+	}
+
+	cfg := &config.Config{
+		ListenAddr:          ":0",
+		MetaDSN:             "memory://" + t.Name(),
+		Storages:            urls,
+		ErasureDataShards:   3,
+		ErasureParityShards: 2,
+		ErasureStripeBytes:  1000,
+	}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := bytes.Repeat([]byte("finalized"), 500)
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	chunked, err := uploadChunked(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("chunked upload: %v", err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	for _, root := range roots {
+		for _, id := range []string{res.FileID, chunked.FileID} {
+			metaPath := filepath.Join(root, id, "meta.json")
+			if err = os.Chtimes(metaPath, old, old); err != nil {
+				t.Fatalf("backdate %s: %v", metaPath, err)
+			}
+		}
+		if err = storagehttp.SweepOnce(root, 24*time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, id := range []string{res.FileID, chunked.FileID} {
+		got, err := downloadFile(restSrv.URL + "/files/" + id)
+		if err != nil || !bytes.Equal(got, payload) {
+			t.Fatalf("download %s after GC: %v", id, err)
+		}
+		for _, root := range roots {
+			if _, err = os.Stat(filepath.Join(root, id)); err != nil {
+				t.Fatalf("GC removed a finalized file dir: %v", err)
+			}
+		}
+	}
+}
+
+// Завершённые части GC не трогает: если файл не удалось завершить на всех узлах,
+// его части уходят в очередь удаления, а не остаются на узлах навсегда.
+func Test_StorageGC_FailedFinalizeLeavesNoParts(t *testing.T) {
+	var roots []string
+	node := func(rejectFinalize bool) string {
+		root := t.TempDir()
+		roots = append(roots, root)
+		storage := storagehttp.New(root)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rejectFinalize && strings.HasSuffix(r.URL.Path, "/finalize") {
+				http.Error(w, "disk failure", http.StatusInternalServerError)
+				return
+			}
+			storage.ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{node(false), node(true)}}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	if _, err = uploadFile(restSrv.URL+"/files", bytes.Repeat([]byte("finalize"), 1000)); err == nil {
+		t.Fatalf("upload must fail when a node rejects finalize")
+	}
+	if _, err = srv.FilesService.RetryPendingDeletions(context.Background()); err != nil {
+		t.Fatalf("retry deletions: %v", err)
+	}
+	for _, root := range roots {
+		entries, _ := os.ReadDir(root)
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), ".") {
+				t.Fatalf("parts of the failed upload left in %s: %s", root, e.Name())
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+type listResponse struct {
+	Files []struct {
+		FileID string `json:"file_id"`
+		Name   string `json:"file_name"`
+		Size   int64  `json:"size"`
+	} `json:"files"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func TestListFiles_PrefixAndPagination(t *testing.T) {
+	dsns := map[string]string{
+		// Именованные memory-хранилища живут весь процесс: уникальное имя изолирует повторные прогоны.
+		"memory": fmt.Sprintf("memory://%s-%d", t.Name(), time.Now().UnixNano()),
+		"bolt":   "bolt://" + filepath.Join(t.TempDir(), "meta.db"),
+	}
+
+	for backend, dsn := range dsns {
+		t.Run(backend, func(t *testing.T) {
+			node := httptest.NewServer(storagehttp.New(t.TempDir()))
+			t.Cleanup(node.Close)
+
+			cfg := &config.Config{ListenAddr: ":0", MetaDSN: dsn, Storages: []string{node.URL}}
+			handler, srv, err := resthttp.NewServer(cfg)
+			if err != nil {
+				t.Fatalf("new rest server: %v", err)
+			}
+			t.Cleanup(srv.Close)
+			restSrv := httptest.NewServer(handler)
+			t.Cleanup(restSrv.Close)
+
+			sizes := []int{300, 100, 500, 200, 400}
+			for i, size := range sizes {
+				if _, err = uploadNamed(restSrv.URL, fmt.Sprintf("logs/%d.txt", i), bytes.Repeat([]byte{'x'}, size)); err != nil {
+					t.Fatalf("upload: %v", err)
+				}
+			}
+			if _, err = uploadNamed(restSrv.URL, "other.bin", []byte("zzz")); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+
+			var (
+				got    []int64
+				cursor string
+				pages  int
+			)
+			for {
+				q := url.Values{"prefix": {"logs/"}, "limit": {"2"}, "sort": {"size"}, "order": {"desc"}}
+				if cursor != "" {
+					q.Set("cursor", cursor)
+				}
+				page, status := listFiles(t, restSrv.URL+"/files?"+q.Encode())
+				if status != http.StatusOK {
+					t.Fatalf("list status %d", status)
+				}
+				for _, f := range page.Files {
+					got = append(got, f.Size)
+				}
+				pages++
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+
+			want := []int64{500, 400, 300, 200, 100}
+			if fmt.Sprint(got) != fmt.Sprint(want) || pages != 3 {
+				t.Fatalf("listed sizes %v in %d pages, want %v in 3 pages", got, pages, want)
+			}
+
+			if _, status := listFiles(t, restSrv.URL+"/files?cursor=garbage"); status != http.StatusBadRequest {
+				t.Fatalf("bad cursor: status %d", status)
+			}
+		})
+	}
+}
+
+func uploadNamed(base, name string, data []byte) (uploadResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, base+"/files", bytes.NewReader(data))
+	if err != nil {
+		return uploadResponse{}, err
+	}
+	req.Header.Set("X-File-Name", name)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return uploadResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return uploadResponse{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var out uploadResponse
+	return out, json.NewDecoder(resp.Body).Decode(&out)
+}
+
+func listFiles(t *testing.T, u string) (listResponse, int) {
+	t.Helper()
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out listResponse
+	if resp.StatusCode == http.StatusOK {
+		if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("decode list: %v", err)
+		}
+	}
+	return out, resp.StatusCode
+}
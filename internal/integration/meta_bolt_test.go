@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+	"github.com/sir_venger/s3_lite/internal/repo/meta"
+)
+
+func TestBoltMetaStore_PersistsAcrossRestart(t *testing.T) {
+	storageSrv := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(storageSrv.Close)
+
+	dsn := "bolt://" + filepath.Join(t.TempDir(), "meta.db")
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: dsn, Storages: []string{storageSrv.URL}}
+
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	restSrv := httptest.NewServer(handler)
+
+	payload := bytes.Repeat([]byte("bolt"), 4096)
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload file: %v", err)
+	}
+	restSrv.Close()
+	srv.Close()
+
+	// Повторное открытие того же файла базы должно вернуть сохранённые метаданные.
+	store, err := meta.Open(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("reopen bolt store: %v", err)
+	}
+	defer store.Close()
+
+	file, err := store.Get(context.Background(), res.FileID)
+	if err != nil {
+		t.Fatalf("get file: %v", err)
+	}
+	if file.Size != int64(len(payload)) || len(file.Parts) != file.TotalParts {
+		t.Fatalf("unexpected meta after reopen: %+v", file)
+	}
+}
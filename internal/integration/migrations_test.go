@@ -0,0 +1,29 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/repo/migrations"
+)
+
+func TestEmbeddedMigrations_AreOrderedAndHaveDownSections(t *testing.T) {
+	all, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if len(all) == 0 {
+		t.Fatalf("no embedded migrations")
+	}
+
+	var prev int64
+	for _, m := range all {
+		if m.Version <= prev {
+			t.Fatalf("migration %s is out of order", m.Name)
+		}
+		prev = m.Version
+
+		if m.Up == "" || m.Down == "" {
+			t.Fatalf("migration %s must have both up and down sections", m.Name)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+type uploadSession struct {
+	UploadID string `json:"upload_id"`
+	Name     string `json:"file_name"`
+	Parts    []struct {
+		PartNumber int    `json:"part_number"`
+		Size       int64  `json:"size"`
+		Sha256     string `json:"sha256"`
+	} `json:"parts"`
+}
+
+func TestMultipartUpload_Sessions(t *testing.T) {
+	var nodes []string
+	for i := 0; i < 2; i++ {
+		node := httptest.NewServer(storagehttp.New(t.TempDir()))
+		t.Cleanup(node.Close)
+		nodes = append(nodes, node.URL)
+	}
+	dsn := "bolt://" + filepath.Join(t.TempDir(), "meta.db")
+	start := func() (string, func()) {
+		cfg := &config.Config{ListenAddr: ":0", MetaDSN: dsn, Storages: nodes}
+		handler, srv, err := resthttp.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("new rest server: %v", err)
+		}
+		restSrv := httptest.NewServer(handler)
+		return restSrv.URL, func() {
+			restSrv.Close()
+			srv.Close()
+		}
+	}
+	base, stop := start()
+
+	do := func(method, url string, body []byte, header ...string) (int, []byte) {
+		t.Helper()
+		req, _ := http.NewRequest(method, url, bytes.NewReader(body))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, out
+	}
+	create := func(name string) string {
+		t.Helper()
+		status, body := do(http.MethodPost, base+"/uploads", nil, "X-File-Name", name)
+		var s uploadSession
+		if status != http.StatusCreated || json.Unmarshal(body, &s) != nil || s.UploadID == "" {
+			t.Fatalf("create upload: %d %s", status, body)
+		}
+		return s.UploadID
+	}
+	partURL := func(id string, n int) string {
+		return base + "/uploads/" + id + "/parts/" + strconv.Itoa(n)
+	}
+
+	part1 := bytes.Repeat([]byte("a"), 3000)
+	part2 := bytes.Repeat([]byte("b"), 1200)
+	id := create("big.bin")
+
+	// Части приходят не по порядку, первая — дважды: сначала с ошибкой, затем исправленная.
+	if status, body := do(http.MethodPut, partURL(id, 2), part2); status != http.StatusOK {
+		t.Fatalf("put part 2: %d %s", status, body)
+	}
+	if status, body := do(http.MethodPut, partURL(id, 1), []byte("stale")); status != http.StatusOK {
+		t.Fatalf("put part 1: %d %s", status, body)
+	}
+	sum := sha256.Sum256(part1)
+	if status, _ := do(http.MethodPut, partURL(id, 1), part1, "X-Checksum-Sha256", "deadbeef"); status != http.StatusBadRequest {
+		t.Fatalf("checksum mismatch: status %d, want 400", status)
+	}
+	if status, _ := do(http.MethodPut, partURL(id, 0), part1); status != http.StatusBadRequest {
+		t.Fatalf("part number 0: status %d, want 400", status)
+	}
+
+	// Сессия переживает перезапуск REST.
+	stop()
+	base, stop = start()
+	t.Cleanup(stop)
+
+	if status, body := do(http.MethodPut, partURL(id, 1), part1, "X-Checksum-Sha256", hex.EncodeToString(sum[:])); status != http.StatusOK {
+		t.Fatalf("retry part 1: %d %s", status, body)
+	}
+
+	status, body := do(http.MethodGet, base+"/uploads/"+id, nil)
+	var session uploadSession
+	if status != http.StatusOK || json.Unmarshal(body, &session) != nil {
+		t.Fatalf("get upload: %d %s", status, body)
+	}
+	if len(session.Parts) != 2 || session.Parts[0].PartNumber != 1 || session.Parts[0].Size != int64(len(part1)) {
+		t.Fatalf("unexpected parts: %+v", session.Parts)
+	}
+
+	status, body = do(http.MethodPost, base+"/uploads/"+id+"/complete", nil)
+	var res uploadResponse
+	if status != http.StatusOK || json.Unmarshal(body, &res) != nil {
+		t.Fatalf("complete: %d %s", status, body)
+	}
+	if res.FileID != id || res.Size != int64(len(part1)+len(part2)) || res.Parts != 2 {
+		t.Fatalf("unexpected complete result: %+v", res)
+	}
+	got, err := downloadFile(base + "/files/" + id)
+	if err != nil || !bytes.Equal(got, append(append([]byte{}, part1...), part2...)) {
+		t.Fatalf("download assembled file: %v", err)
+	}
+	if status, _ = do(http.MethodGet, base+"/uploads/"+id, nil); status != http.StatusNotFound {
+		t.Fatalf("completed session must be gone, status %d", status)
+	}
+
+	// Пропуск в нумерации не даёт завершить загрузку; отмена удаляет сессию.
+	gap := create("gap.bin")
+	if status, _ = do(http.MethodPut, partURL(gap, 2), part2); status != http.StatusOK {
+		t.Fatalf("put part 2: %d", status)
+	}
+	if status, _ = do(http.MethodPost, base+"/uploads/"+gap+"/complete", nil); status != http.StatusBadRequest {
+		t.Fatalf("complete with gap: status %d, want 400", status)
+	}
+	if status, _ = do(http.MethodDelete, base+"/uploads/"+gap, nil); status != http.StatusNoContent {
+		t.Fatalf("abort: status %d", status)
+	}
+	if status, _ = do(http.MethodPut, partURL(gap, 1), part1); status != http.StatusNotFound {
+		t.Fatalf("put into aborted session: status %d, want 404", status)
+	}
+}
+
+func TestMultipartUpload_ExpiredSessionsAreRemoved(t *testing.T) {
+	root := t.TempDir()
+	node := httptest.NewServer(storagehttp.New(root))
+	t.Cleanup(node.Close)
+
+	cfg := &config.Config{
+		ListenAddr:   ":0",
+		MetaDSN:      "bolt://" + filepath.Join(t.TempDir(), "meta.db"),
+		Storages:     []string{node.URL},
+		UploadTTLSec: 1,
+	}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	resp, err := http.Post(restSrv.URL+"/uploads", "application/octet-stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var session struct {
+		UploadID  string    `json:"upload_id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&session)
+	_ = resp.Body.Close()
+	if err != nil || session.UploadID == "" || session.ExpiresAt.IsZero() {
+		t.Fatalf("create upload: %v %+v", err, session)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, restSrv.URL+"/uploads/"+session.UploadID+"/parts/1", bytes.NewReader([]byte("expiring part")))
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("put part: %s", resp.Status)
+	}
+	if _, err = os.Stat(filepath.Join(root, session.UploadID)); err != nil {
+		t.Fatalf("part is not on the storage: %v", err)
+	}
+
+	// Истёкшая сессия недоступна сразу, даже если фоновая очистка до неё ещё не дошла.
+	time.Sleep(time.Until(session.ExpiresAt) + 50*time.Millisecond)
+	for _, path := range []string{"", "/complete"} {
+		method := http.MethodGet
+		if path != "" {
+			method = http.MethodPost
+		}
+		req, _ = http.NewRequest(method, restSrv.URL+"/uploads/"+session.UploadID+path, nil)
+		if resp, err = http.DefaultClient.Do(req); err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("%s expired upload%s: status %s, want 404", method, path, resp.Status)
+		}
+	}
+
+	n, err := srv.FilesService.ExpireUploads(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("expire uploads: %d, %v", n, err)
+	}
+	if _, err = os.Stat(filepath.Join(root, session.UploadID)); !os.IsNotExist(err) {
+		t.Fatalf("parts of the expired upload are left on the storage: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestPresignedURLs(t *testing.T) {
+	node := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(node.Close)
+
+	const adminKey = "presign-admin"
+	dsn := fmt.Sprintf("memory://%s-%d", t.Name(), time.Now().UnixNano())
+	oldKey, newKey := config.PresignKey{ID: "k1", Secret: "old-secret"}, config.PresignKey{ID: "k2", Secret: "new-secret"}
+
+	start := func(keys ...config.PresignKey) string {
+		t.Helper()
+		cfg := &config.Config{
+			ListenAddr:  ":0",
+			MetaDSN:     dsn,
+			Storages:    []string{node.URL},
+			AuthEnabled: true,
+			AdminAPIKey: adminKey,
+			PresignKeys: keys,
+		}
+		handler, srv, err := resthttp.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("new rest server: %v", err)
+		}
+		t.Cleanup(srv.Close)
+		restSrv := httptest.NewServer(handler)
+		t.Cleanup(restSrv.Close)
+		return restSrv.URL
+	}
+	do := func(base, key, method, path string, body io.Reader) (int, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(method, base+path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, data
+	}
+	presign := func(base, key, path, body string) string {
+		t.Helper()
+		status, data := do(base, key, http.MethodPost, path, strings.NewReader(body))
+		if status != http.StatusOK {
+			t.Fatalf("presign %s: %d %s", path, status, data)
+		}
+		var resp struct {
+			URL string `json:"url"`
+		}
+		_ = json.Unmarshal(data, &resp)
+		return resp.URL
+	}
+
+	base := start(oldKey)
+	status, data := do(base, adminKey, http.MethodPost, "/files", bytes.NewReader([]byte("shared content")))
+	if status != http.StatusOK {
+		t.Fatalf("upload: %d %s", status, data)
+	}
+	var res uploadResponse
+	_ = json.Unmarshal(data, &res)
+
+	// Ссылка на скачивание работает без ключа API и только для своего метода и пути.
+	link := presign(base, adminKey, "/files/"+res.FileID+"/presign", `{"expires_in_sec":600}`)
+	if status, data = do(base, "", http.MethodGet, link, nil); status != http.StatusOK || string(data) != "shared content" {
+		t.Fatalf("presigned get: %d %q", status, data)
+	}
+	if status, _ = do(base, "", http.MethodHead, link, nil); status != http.StatusOK {
+		t.Fatalf("presigned head: %d", status)
+	}
+	if status, _ = do(base, "", http.MethodDelete, link, nil); status != http.StatusForbidden {
+		t.Fatalf("presigned delete: %d", status)
+	}
+	if status, _ = do(base, "", http.MethodGet, strings.Replace(link, res.FileID, "00000000-0000-0000-0000-000000000000", 1), nil); status != http.StatusForbidden {
+		t.Fatalf("tampered path: %d", status)
+	}
+	if status, _ = do(base, "", http.MethodGet, "/files/"+res.FileID, nil); status != http.StatusUnauthorized {
+		t.Fatalf("unsigned get: %d", status)
+	}
+
+	// Ссылка на загрузку: имя из подписи, тело не больше max_size.
+	upload := presign(base, adminKey, "/files/presign", `{"max_size":16,"filename":"from-browser.txt"}`)
+	status, data = do(base, "", http.MethodPost, upload, strings.NewReader("small body"))
+	if status != http.StatusOK {
+		t.Fatalf("presigned upload: %d %s", status, data)
+	}
+	var uploaded uploadResponse
+	_ = json.Unmarshal(data, &uploaded)
+	if status, data = do(base, adminKey, http.MethodGet, "/files/"+uploaded.FileID+"/meta", nil); status != http.StatusOK || !strings.Contains(string(data), "from-browser.txt") {
+		t.Fatalf("uploaded meta: %d %s", status, data)
+	}
+	if status, _ = do(base, "", http.MethodPost, upload, strings.NewReader(strings.Repeat("x", 17))); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: %d", status)
+	}
+	// Без Content-Length предел срабатывает при чтении тела.
+	chunked := io.MultiReader(strings.NewReader(strings.Repeat("y", 10)), strings.NewReader(strings.Repeat("y", 10)))
+	if status, _ = do(base, "", http.MethodPost, upload, chunked); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized chunked upload: %d", status)
+	}
+
+	// Ротация: после смены ключа старые ссылки живут, пока старый ключ остаётся в списке.
+	rotated := start(newKey, oldKey)
+	if status, _ = do(rotated, "", http.MethodGet, link, nil); status != http.StatusOK {
+		t.Fatalf("old link after rotation: %d", status)
+	}
+	fresh := presign(rotated, adminKey, "/files/"+res.FileID+"/presign", "")
+	if !strings.Contains(fresh, "X-Key-Id=k2") {
+		t.Fatalf("new link is not signed by the new key: %s", fresh)
+	}
+	retired := start(newKey)
+	if status, _ = do(retired, "", http.MethodGet, link, nil); status != http.StatusForbidden {
+		t.Fatalf("link of retired key: %d", status)
+	}
+	if status, _ = do(retired, "", http.MethodGet, fresh, nil); status != http.StatusOK {
+		t.Fatalf("fresh link: %d", status)
+	}
+
+	short := presign(rotated, adminKey, "/files/"+res.FileID+"/presign", `{"expires_in_sec":1}`)
+	time.Sleep(2 * time.Second)
+	if status, data = do(rotated, "", http.MethodGet, short, nil); status != http.StatusForbidden || !strings.Contains(string(data), "expired") {
+		t.Fatalf("expired link: %d %s", status, data)
+	}
+}
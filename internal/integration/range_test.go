@@ -0,0 +1,221 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+	"github.com/sir_venger/s3_lite/pkg/storageclient"
+)
+
+func TestGetFile_RangeRequests(t *testing.T) {
+	// ranged считает запросы частей, пришедшие на стораджи с заголовком Range.
+	var ranged atomic.Int64
+	node := func() *httptest.Server {
+		storage := storagehttp.New(t.TempDir())
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") != "" {
+				ranged.Add(1)
+			}
+			storage.ServeHTTP(w, r)
+		}))
+	}
+	s1, s2 := node(), node()
+	t.Cleanup(func() { s1.Close(); s2.Close() })
+
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{s1.URL, s2.URL}}
+	handler, _, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := make([]byte, 10007)
+	for i := range payload {
+		payload[i] = byte(i * 31)
+	}
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload file: %v", err)
+	}
+	fileURL := restSrv.URL + "/files/" + res.FileID
+	size := int64(len(payload))
+
+	cases := []struct {
+		name      string
+		rng       string
+		status    int
+		want      []byte
+		wantRange string
+	}{
+		{"across parts", "bytes=100-5000", http.StatusPartialContent, payload[100:5001], fmt.Sprintf("bytes 100-5000/%d", size)},
+		{"open ended", "bytes=9000-", http.StatusPartialContent, payload[9000:], fmt.Sprintf("bytes 9000-%d/%d", size-1, size)},
+		{"suffix", "bytes=-10", http.StatusPartialContent, payload[size-10:], fmt.Sprintf("bytes %d-%d/%d", size-10, size-1, size)},
+		{"end clamped", "bytes=10000-99999", http.StatusPartialContent, payload[10000:], fmt.Sprintf("bytes 10000-%d/%d", size-1, size)},
+		{"unsatisfiable", "bytes=20000-", http.StatusRequestedRangeNotSatisfiable, nil, fmt.Sprintf("bytes */%d", size)},
+		{"multi range ignored", "bytes=0-1,5-6", http.StatusOK, payload, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, got, hdr := rangeGet(t, fileURL, tc.rng, "")
+			if status != tc.status {
+				t.Fatalf("status %d, want %d", status, tc.status)
+			}
+			if hdr.Get("Content-Range") != tc.wantRange {
+				t.Fatalf("Content-Range %q, want %q", hdr.Get("Content-Range"), tc.wantRange)
+			}
+			if tc.want != nil && !bytes.Equal(got, tc.want) {
+				t.Fatalf("body mismatch: got %d bytes, want %d", len(got), len(tc.want))
+			}
+		})
+	}
+
+	// Крайние части диапазона запрашиваются у стораджей диапазоном, а не целиком.
+	ranged.Store(0)
+	if status, _, _ := rangeGet(t, fileURL, "bytes=100-5000", ""); status != http.StatusPartialContent {
+		t.Fatalf("range: status %d", status)
+	}
+	if n := ranged.Load(); n != 2 {
+		t.Fatalf("storages got %d ranged part requests, want 2", n)
+	}
+
+	_, _, hdr := rangeGet(t, fileURL, "", "")
+	etag := hdr.Get("ETag")
+	if etag == "" {
+		t.Fatalf("missing ETag")
+	}
+	if status, _, _ := rangeGet(t, fileURL, "bytes=0-9", etag); status != http.StatusPartialContent {
+		t.Fatalf("matching If-Range: status %d", status)
+	}
+	if status, got, _ := rangeGet(t, fileURL, "bytes=0-9", `"stale"`); status != http.StatusOK || !bytes.Equal(got, payload) {
+		t.Fatalf("stale If-Range must return the whole file, got status %d", status)
+	}
+}
+
+func TestGetFile_SmallRangeReadsOnlyRequestedBytes(t *testing.T) {
+	// served считает байты тел, отданных стораджем по /parts.
+	var served atomic.Int64
+	storage := storagehttp.New(t.TempDir())
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storage.ServeHTTP(&countingWriter{ResponseWriter: w, n: &served}, r)
+	}))
+	t.Cleanup(node.Close)
+
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{node.URL}}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 1<<16) // 1 MiB, части по ~170 KiB
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload file: %v", err)
+	}
+
+	served.Store(0)
+	status, got, _ := rangeGet(t, restSrv.URL+"/files/"+res.FileID, "bytes=0-99", "")
+	if status != http.StatusPartialContent || !bytes.Equal(got, payload[:100]) {
+		t.Fatalf("range: status %d, %d bytes", status, len(got))
+	}
+	if n := served.Load(); n != 100 {
+		t.Fatalf("storage served %d bytes for a 100-byte range", n)
+	}
+}
+
+// countingWriter считает байты тела ответа, записанные через него.
+type countingWriter struct {
+	http.ResponseWriter
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func rangeGet(t *testing.T, url, rng, ifRange string) (int, []byte, http.Header) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+	if ifRange != "" {
+		req.Header.Set("If-Range", ifRange)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body, resp.Header
+}
+
+func TestStoragePart_RangeReads(t *testing.T) {
+	node := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(node.Close)
+
+	cli := storageclient.New()
+	ctx := context.Background()
+	data := []byte("0123456789abcdefghij")
+	err := cli.PutPart(ctx, node.URL, storageclient.PutPartRequest{
+		FileID:     "f1",
+		Index:      0,
+		Reader:     bytes.NewReader(data),
+		Size:       int64(len(data)),
+		TotalParts: 1,
+	})
+	if err != nil {
+		t.Fatalf("put part: %v", err)
+	}
+
+	status, got, hdr := rangeGet(t, node.URL+"/parts/f1/0", "bytes=5-9", "")
+	if status != http.StatusPartialContent || string(got) != "56789" {
+		t.Fatalf("part range: status %d body %q", status, got)
+	}
+	if hdr.Get("Content-Range") != "bytes 5-9/20" {
+		t.Fatalf("part Content-Range %q", hdr.Get("Content-Range"))
+	}
+
+	rc, err := cli.GetPartRange(ctx, node.URL, "f1", 0, 10, 4)
+	if err != nil {
+		t.Fatalf("get part range: %v", err)
+	}
+	got, _ = io.ReadAll(rc)
+	_ = rc.Close()
+	if string(got) != "abcd" {
+		t.Fatalf("GetPartRange returned %q", got)
+	}
+
+	resp, err := http.Head(node.URL + "/parts/f1/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.Header.Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("HEAD must advertise Accept-Ranges, got %q", resp.Header.Get("Accept-Ranges"))
+	}
+}
@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestReplication_FailoverAndQuorum(t *testing.T) {
+	dirs := make(map[string]string)
+	nodes := make([]*httptest.Server, 3)
+	urls := make([]string, 3)
+	for i := range nodes {
+		dir := t.TempDir()
+		nodes[i] = httptest.NewServer(storagehttp.New(dir))
+		t.Cleanup(nodes[i].Close)
+		urls[i] = nodes[i].URL
+		dirs[nodes[i].URL] = dir
+	}
+
+	cfg := &config.Config{
+		ListenAddr:        ":0",
+		MetaDSN:           "memory://" + t.Name(),
+		Storages:          urls,
+		ReplicationFactor: 2,
+	}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := bytes.Repeat([]byte("replica!"), 750)
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	resp, err := http.Get(restSrv.URL + "/files/" + res.FileID + "/meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta fileMetaResponse
+	err = json.NewDecoder(resp.Body).Decode(&meta)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+	for _, p := range meta.Parts {
+		if len(p.Storages) != 2 || p.Storages[0] == p.Storages[1] || p.Storage != p.Storages[0] {
+			t.Fatalf("part %d: want 2 distinct replicas, got %v (primary %s)", p.Index, p.Storages, p.Storage)
+		}
+	}
+
+	// Портим основную копию части 0: чтение должно уйти на реплику.
+	primary := meta.Parts[0].Storage
+	path := filepath.Join(dirs[primary], res.FileID, "0.part")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 0xFF
+	if err = os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := downloadFile(restSrv.URL + "/files/" + res.FileID)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("download with corrupted primary: %v", err)
+	}
+
+	// Узел целиком недоступен: у каждой части остаётся копия на другом.
+	nodes[0].Close()
+	got, err = downloadFile(restSrv.URL + "/files/" + res.FileID)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("download with node down: %v", err)
+	}
+
+	// Двух живых узлов хватает для фактора 2, а кворум 3 уже недостижим.
+	if _, err = uploadFile(restSrv.URL+"/files", payload); err != nil {
+		t.Fatalf("upload with one node down: %v", err)
+	}
+
+	cfg3 := *cfg
+	cfg3.MetaDSN = "memory://" + t.Name() + "-quorum"
+	cfg3.ReplicationFactor = 3
+	cfg3.WriteQuorum = 3
+	handler3, srv3, err := resthttp.NewServer(&cfg3)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv3.Close)
+	rest3 := httptest.NewServer(handler3)
+	t.Cleanup(rest3.Close)
+	if _, err = uploadFile(rest3.URL+"/files", payload); err == nil {
+		t.Fatalf("upload must fail without write quorum")
+	}
+
+	// Удаление убирает копии со всех живых узлов.
+	req, _ := http.NewRequest(http.MethodDelete, restSrv.URL+"/files/"+res.FileID, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status %s", resp.Status)
+	}
+	for _, u := range urls[1:] {
+		assertNoFileDir(t, dirs[u], res.FileID)
+	}
+}
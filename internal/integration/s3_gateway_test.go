@@ -0,0 +1,183 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+const (
+	s3AccessKey = "s3lite-test"
+	s3SecretKey = "s3lite-test-secret"
+)
+
+func TestS3Gateway_WithMinioClient(t *testing.T) {
+	var nodes []string
+	for i := 0; i < 3; i++ {
+		node := httptest.NewServer(storagehttp.New(t.TempDir()))
+		t.Cleanup(node.Close)
+		nodes = append(nodes, node.URL)
+	}
+
+	cfg := &config.Config{
+		MetaDSN:       "memory://s3-gateway",
+		Storages:      nodes,
+		S3Credentials: []config.S3Credential{{AccessKey: s3AccessKey, SecretKey: s3SecretKey}},
+	}
+	handler, srv, err := resthttp.NewS3Server(cfg)
+	if err != nil {
+		t.Fatalf("new s3 server: %v", err)
+	}
+	gw := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		gw.Close()
+		srv.Close()
+	})
+
+	newClient := func(secret string) *minio.Client {
+		cli, err := minio.New(strings.TrimPrefix(gw.URL, "http://"), &minio.Options{
+			Creds:  credentials.NewStaticV4(s3AccessKey, secret, ""),
+			Region: "us-east-1",
+		})
+		if err != nil {
+			t.Fatalf("minio client: %v", err)
+		}
+		return cli
+	}
+	cli := newClient(s3SecretKey)
+	ctx := context.Background()
+
+	if err = cli.MakeBucket(ctx, "photos", minio.MakeBucketOptions{}); err != nil {
+		t.Fatalf("make bucket: %v", err)
+	}
+	if ok, err := cli.BucketExists(ctx, "photos"); err != nil || !ok {
+		t.Fatalf("bucket exists: %v %v", ok, err)
+	}
+	if ok, err := cli.BucketExists(ctx, "missing"); err != nil || ok {
+		t.Fatalf("missing bucket exists: %v %v", ok, err)
+	}
+	if buckets, err := cli.ListBuckets(ctx); err != nil || len(buckets) != 1 || buckets[0].Name != "photos" {
+		t.Fatalf("list buckets: %+v %v", buckets, err)
+	}
+
+	put := func(key string, data []byte, opts minio.PutObjectOptions) {
+		t.Helper()
+		if _, err := cli.PutObject(ctx, "photos", key, bytes.NewReader(data), int64(len(data)), opts); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	get := func(key string, opts minio.GetObjectOptions) []byte {
+		t.Helper()
+		obj, err := cli.GetObject(ctx, "photos", key, opts)
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		defer obj.Close()
+		data, err := io.ReadAll(obj)
+		if err != nil {
+			t.Fatalf("read %s: %v", key, err)
+		}
+		return data
+	}
+
+	// Подписанные чанки aws-chunked и Range.
+	small := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	put("2024/a.txt", small, minio.PutObjectOptions{})
+	if got := get("2024/a.txt", minio.GetObjectOptions{}); !bytes.Equal(got, small) {
+		t.Fatalf("get a.txt = %q", got)
+	}
+	rng := minio.GetObjectOptions{}
+	_ = rng.SetRange(10, 19)
+	if got := get("2024/a.txt", rng); string(got) != "abcdefghij" {
+		t.Fatalf("range get = %q", got)
+	}
+
+	// Составная загрузка: 11 MiB частями по 5 MiB.
+	big := make([]byte, 11<<20)
+	_, _ = rand.Read(big)
+	put("2024/big.bin", big, minio.PutObjectOptions{PartSize: 5 << 20})
+	if got := get("2024/big.bin", minio.GetObjectOptions{}); !bytes.Equal(got, big) {
+		t.Fatalf("multipart object differs")
+	}
+	info, err := cli.StatObject(ctx, "photos", "2024/big.bin", minio.StatObjectOptions{})
+	if err != nil || info.Size != int64(len(big)) || !strings.HasSuffix(info.ETag, "-3") {
+		t.Fatalf("stat big: %+v %v", info, err)
+	}
+
+	// Перезапись ключа оставляет одну, новую версию.
+	put("2024/a.txt", []byte("v2"), minio.PutObjectOptions{})
+	if got := get("2024/a.txt", minio.GetObjectOptions{}); string(got) != "v2" {
+		t.Fatalf("overwritten a.txt = %q", got)
+	}
+	put("2025/c.txt", []byte("c"), minio.PutObjectOptions{})
+	put("root.txt", []byte("r"), minio.PutObjectOptions{})
+
+	list := func(opts minio.ListObjectsOptions) []string {
+		t.Helper()
+		var keys []string
+		for obj := range cli.ListObjects(ctx, "photos", opts) {
+			if obj.Err != nil {
+				t.Fatalf("list: %v", obj.Err)
+			}
+			keys = append(keys, obj.Key)
+		}
+		return keys
+	}
+	if got := strings.Join(list(minio.ListObjectsOptions{Recursive: true, MaxKeys: 2}), ","); got != "2024/a.txt,2024/big.bin,2025/c.txt,root.txt" {
+		t.Fatalf("recursive list = %s", got)
+	}
+	if got := strings.Join(list(minio.ListObjectsOptions{MaxKeys: 1}), ","); got != "2024/,2025/,root.txt" {
+		t.Fatalf("delimited list = %s", got)
+	}
+	if got := strings.Join(list(minio.ListObjectsOptions{Prefix: "2024/", Recursive: true}), ","); got != "2024/a.txt,2024/big.bin" {
+		t.Fatalf("prefix list = %s", got)
+	}
+
+	// Presigned URL без заголовка Authorization.
+	u, err := cli.PresignedGetObject(ctx, "photos", "root.txt", time.Minute, nil)
+	if err != nil {
+		t.Fatalf("presign: %v", err)
+	}
+	resp, err := http.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "r" {
+		t.Fatalf("presigned get: %d %q", resp.StatusCode, body)
+	}
+
+	if err = cli.RemoveObject(ctx, "photos", "2024/a.txt", minio.RemoveObjectOptions{}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err = cli.StatObject(ctx, "photos", "2024/a.txt", minio.StatObjectOptions{}); minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		t.Fatalf("stat removed object: %v", err)
+	}
+
+	// Чужой секрет и запрос без подписи отвергаются.
+	_, err = newClient("wrong-secret").StatObject(ctx, "photos", "root.txt", minio.StatObjectOptions{})
+	if resp := minio.ToErrorResponse(err); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("wrong secret: %v", err)
+	}
+	resp, err = http.Get(gw.URL + "/photos/root.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("anonymous get: %d", resp.StatusCode)
+	}
+}
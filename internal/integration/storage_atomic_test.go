@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/pkg/storageproto"
+)
+
+func TestStorage_AtomicPartWrites(t *testing.T) {
+	root := t.TempDir()
+	node := httptest.NewServer(storagehttp.New(root))
+	t.Cleanup(node.Close)
+
+	put := func(fileID string, idx int, body, sha string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf(storageproto.PartsPathFormat, node.URL, fileID, idx), strings.NewReader(body))
+		req.Header.Set(storageproto.HeaderChecksum, sha)
+		req.Header.Set(storageproto.HeaderTotalParts, "2")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	get := func(fileID string, idx int) (int, string) {
+		t.Helper()
+		resp, err := http.Get(fmt.Sprintf(storageproto.PartsPathFormat, node.URL, fileID, idx))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+	assertNoTemp := func(fileID string) {
+		t.Helper()
+		entries, _ := os.ReadDir(filepath.Join(root, fileID))
+		for _, e := range entries {
+			if strings.Contains(e.Name(), ".tmp-") {
+				t.Fatalf("temp file left behind: %s", e.Name())
+			}
+		}
+	}
+
+	// Часть с неверной контрольной суммой не появляется на диске.
+	if status := put("bad", 0, "payload", sum("other")); status != http.StatusConflict {
+		t.Fatalf("bad checksum: %d", status)
+	}
+	if status, _ := get("bad", 0); status != http.StatusNotFound {
+		t.Fatalf("rejected part is served: %d", status)
+	}
+	assertNoTemp("bad")
+
+	// Неудачная перезапись не портит уже записанную часть и её meta.json.
+	if status := put("good", 0, "original", sum("original")); status != http.StatusCreated {
+		t.Fatalf("put: %d", status)
+	}
+	if status := put("good", 0, "replaced", sum("corrupted")); status != http.StatusConflict {
+		t.Fatalf("bad overwrite: %d", status)
+	}
+	if status, body := get("good", 0); status != http.StatusOK || body != "original" {
+		t.Fatalf("after failed overwrite: %d %q", status, body)
+	}
+	meta, err := os.ReadFile(filepath.Join(root, "good", "meta.json"))
+	if err != nil || !strings.Contains(string(meta), sum("original")) {
+		t.Fatalf("meta.json: %s %v", meta, err)
+	}
+	assertNoTemp("good")
+
+	// GC убирает брошенные временные файлы, но не трогает свежие.
+	stale := filepath.Join(root, "good", ".1.part.tmp-crashed")
+	fresh := filepath.Join(root, "good", ".1.part.tmp-writing")
+	for _, path := range []string{stale, fresh} {
+		if err = os.WriteFile(path, []byte("half"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	_ = os.Chtimes(stale, old, old)
+	if err = storagehttp.SweepOnce(root, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale temp file not removed")
+	}
+	if _, err = os.Stat(fresh); err != nil {
+		t.Fatalf("fresh temp file removed: %v", err)
+	}
+}
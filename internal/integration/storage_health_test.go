@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+	"github.com/sir_venger/s3_lite/pkg/storageproto"
+)
+
+type storageHealthResponse struct {
+	OK         bool  `json:"ok"`
+	FreeBytes  int64 `json:"free_bytes"`
+	TotalBytes int64 `json:"total_bytes"`
+	UsedBytes  int64 `json:"used_bytes"`
+	Parts      int64 `json:"parts"`
+	Files      int64 `json:"files"`
+}
+
+func TestStorage_HealthReportsCapacity(t *testing.T) {
+	root := t.TempDir()
+	// Данные, лежавшие на диске до старта узла, учитываются при первом обходе каталога.
+	if err := os.MkdirAll(filepath.Join(root, "existing"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "existing", "0.part"), []byte("12345"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	node := httptest.NewServer(storagehttp.New(root))
+	t.Cleanup(node.Close)
+
+	health := func() storageHealthResponse {
+		t.Helper()
+		resp, err := http.Get(node.URL + "/health")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var h storageHealthResponse
+		if err = json.NewDecoder(resp.Body).Decode(&h); err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	send := func(method, fileID string, idx int, body string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, fmt.Sprintf(storageproto.PartsPathFormat, node.URL, fileID, idx), strings.NewReader(body))
+		req.Header.Set(storageproto.HeaderTotalParts, "2")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			t.Fatalf("%s %s/%d: %s", method, fileID, idx, resp.Status)
+		}
+	}
+	expect := func(files, parts, used int64) {
+		t.Helper()
+		h := health()
+		if h.Files != files || h.Parts != parts || h.UsedBytes != used {
+			t.Fatalf("health %+v, want files=%d parts=%d used=%d", h, files, parts, used)
+		}
+	}
+
+	h := health()
+	if !h.OK || h.TotalBytes <= 0 || h.FreeBytes <= 0 || h.FreeBytes > h.TotalBytes {
+		t.Fatalf("disk capacity: %+v", h)
+	}
+	expect(1, 1, 5)
+
+	send(http.MethodPut, "a", 0, "0123456789")
+	send(http.MethodPut, "a", 1, "0123")
+	send(http.MethodPut, "b", 0, "xy")
+	expect(3, 4, 21)
+
+	// Перезапись части меняет только объём.
+	send(http.MethodPut, "a", 0, "01")
+	expect(3, 4, 13)
+
+	send(http.MethodDelete, "b", 0, "")
+	expect(2, 3, 11)
+
+	// GC убирает недогруженный файл "a" вместе с его частями.
+	send(http.MethodDelete, "a", 1, "")
+	old := time.Now().Add(-48 * time.Hour)
+	_ = os.Chtimes(filepath.Join(root, "a", "meta.json"), old, old)
+	if err := storagehttp.SweepOnce(root, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	expect(1, 1, 5)
+}
+
+func TestRouting_SkipsStoragesLowOnSpace(t *testing.T) {
+	fullDir, okDir := t.TempDir(), t.TempDir()
+
+	// Узел с почти полным диском: health сообщает 1 KiB свободного места.
+	fullStorage := storagehttp.New(fullDir)
+	full := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			_ = json.NewEncoder(w).Encode(storageHealthResponse{OK: true, FreeBytes: 1 << 10, TotalBytes: 1 << 40})
+			return
+		}
+		fullStorage.ServeHTTP(w, r)
+	}))
+	t.Cleanup(full.Close)
+	ok := httptest.NewServer(storagehttp.New(okDir))
+	t.Cleanup(ok.Close)
+
+	start := func(minFree int64) string {
+		cfg := &config.Config{
+			ListenAddr:          ":0",
+			MetaDSN:             fmt.Sprintf("memory://%s-%d", t.Name(), minFree),
+			Storages:            []string{full.URL, ok.URL},
+			StorageMinFreeBytes: minFree,
+		}
+		handler, srv, err := resthttp.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("new rest server: %v", err)
+		}
+		t.Cleanup(srv.Close)
+		restSrv := httptest.NewServer(handler)
+		t.Cleanup(restSrv.Close)
+		return restSrv.URL
+	}
+	entries := func(dir string) int {
+		des, _ := os.ReadDir(dir)
+		n := 0
+		for _, e := range des {
+			if !strings.HasPrefix(e.Name(), ".") {
+				n++
+			}
+		}
+		return n
+	}
+
+	// По умолчанию порог — 1 GiB: все части уходят на узел со свободным местом.
+	if _, err := uploadFile(start(0)+"/files", bytes.Repeat([]byte("space"), 1200)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if entries(fullDir) != 0 || entries(okDir) != 1 {
+		t.Fatalf("parts routed to a full node: full=%d ok=%d", entries(fullDir), entries(okDir))
+	}
+
+	// Отрицательный порог отключает проверку: распределение идёт по обоим узлам.
+	if _, err := uploadFile(start(-1)+"/files", bytes.Repeat([]byte("space"), 1200)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if entries(fullDir) != 1 {
+		t.Fatalf("disabled threshold must allow the full node")
+	}
+
+	// Порог выше свободного места на всех узлах: запись отклоняется, а не идёт на заполненные узлы.
+	resp, err := http.Post(start(1<<62)+"/files", "application/octet-stream", bytes.NewReader(bytes.Repeat([]byte("space"), 1200)))
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("upload with every node full: %s, want 503", resp.Status)
+	}
+	if entries(fullDir) != 1 || entries(okDir) != 2 {
+		t.Fatalf("parts written to full nodes: full=%d ok=%d", entries(fullDir), entries(okDir))
+	}
+}
@@ -0,0 +1,321 @@
+package integration
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/pkg/storageproto"
+)
+
+const (
+	// gcHelperEnv — каталог данных, который GC обходит во вспомогательном процессе.
+	gcHelperEnv = "STORAGE_GC_HELPER_ROOT"
+	// orphanFileID — брошенная потоковая загрузка: GC считает её просроченной и удаляет.
+	orphanFileID = "orphan"
+)
+
+func TestStorage_ConcurrentMetaUpdates(t *testing.T) {
+	t.Run("in-process", func(t *testing.T) {
+		root := t.TempDir()
+		stressStorageNode(t, root, func() func() int {
+			stop := make(chan struct{})
+			reaped := make(chan int, 1)
+			go func() { reaped <- sweepLoop(root, stop) }()
+
+			return func() int {
+				close(stop)
+				return <-reaped
+			}
+		})
+	})
+	// GC работает в отдельном процессе: от гонок с ним защищает только flock.
+	t.Run("flock", func(t *testing.T) {
+		root := t.TempDir()
+		if err := storagehttp.UseFlock(root); err != nil {
+			t.Skipf("flock: %v", err)
+		}
+		stressStorageNode(t, root, func() func() int {
+			return startGCProcess(t, root)
+		})
+	})
+}
+
+// TestStorage_GCHelperProcess — GC для подтеста flock, запускаемый в отдельном процессе.
+// Обходит каталог, пока не закроется stdin, и печатает, сколько раз удалил брошенную загрузку.
+func TestStorage_GCHelperProcess(t *testing.T) {
+	root := os.Getenv(gcHelperEnv)
+	if root == "" {
+		t.Skip("helper process for TestStorage_ConcurrentMetaUpdates")
+	}
+	if err := storagehttp.UseFlock(root); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		close(stop)
+	}()
+	fmt.Printf("reaped %d\n", sweepLoop(root, stop))
+}
+
+// startGCProcess запускает TestStorage_GCHelperProcess над root и возвращает функцию его остановки.
+func startGCProcess(t *testing.T, root string) func() int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestStorage_GCHelperProcess$", "-test.count=1")
+	cmd.Env = append(os.Environ(), gcHelperEnv+"="+root)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	cmd.Stdout = &out
+	if err = cmd.Start(); err != nil {
+		t.Fatalf("start gc process: %v", err)
+	}
+
+	return func() int {
+		t.Helper()
+		_ = stdin.Close()
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("gc process: %v\n%s", err, out.String())
+		}
+		sc := bufio.NewScanner(strings.NewReader(out.String()))
+		for sc.Scan() {
+			if v, ok := strings.CutPrefix(sc.Text(), "reaped "); ok {
+				n, _ := strconv.Atoi(v)
+				return n
+			}
+		}
+		t.Fatalf("gc process printed no result:\n%s", out.String())
+		return 0
+	}
+}
+
+// sweepLoop непрерывно запускает GC над root, пока не закрыт stop, и возвращает, сколько раз
+// был удалён каталог брошенной загрузки. Её meta.json перед каждым проходом сдвигается в прошлое.
+func sweepLoop(root string, stop <-chan struct{}) int {
+	dir := filepath.Join(root, orphanFileID)
+	past := time.Now().Add(-2 * time.Hour)
+	reaped := 0
+	for {
+		select {
+		case <-stop:
+			return reaped
+		default:
+		}
+		_ = os.Chtimes(filepath.Join(dir, "meta.json"), past, past)
+		_, before := os.Stat(dir)
+		_ = storagehttp.SweepOnce(root, time.Hour)
+		if _, after := os.Stat(dir); before == nil && os.IsNotExist(after) {
+			reaped++
+		}
+	}
+}
+
+// stressStorageNode гоняет запись и удаление частей, пока GC, запущенный startGC, обходит каталог.
+func stressStorageNode(t *testing.T, root string, startGC func() (stop func() int)) {
+	node := httptest.NewServer(storagehttp.New(root))
+	t.Cleanup(node.Close)
+
+	const fileID, parts = "stress", 64
+	body := func(idx, gen int) string { return fmt.Sprintf("part %d gen %d", idx, gen) }
+	send := func(method, id string, idx, gen, total int) error {
+		var req *http.Request
+		url := fmt.Sprintf(storageproto.PartsPathFormat, node.URL, id, idx)
+		if method == http.MethodPut {
+			data := body(idx, gen)
+			sum := sha256.Sum256([]byte(data))
+			req, _ = http.NewRequest(method, url, strings.NewReader(data))
+			req.Header.Set(storageproto.HeaderChecksum, hex.EncodeToString(sum[:]))
+			req.Header.Set(storageproto.HeaderTotalParts, fmt.Sprint(total))
+		} else {
+			req, _ = http.NewRequest(method, url, nil)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s part %d: %s", method, idx, resp.Status)
+		}
+		return nil
+	}
+	// run выполняет операции параллельно, пока GC непрерывно обходит каталог,
+	// и возвращает, сколько раз GC удалил брошенную загрузку.
+	run := func(ops ...func() error) int {
+		t.Helper()
+		stopGC := startGC()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, len(ops))
+		for _, op := range ops {
+			wg.Add(1)
+			go func(op func() error) {
+				defer wg.Done()
+				if err := op(); err != nil {
+					errs <- err
+				}
+			}(op)
+		}
+		wg.Wait()
+		reaped := stopGC()
+		close(errs)
+		for err := range errs {
+			t.Fatal(err)
+		}
+		return reaped
+	}
+	readMeta := func(dir string) map[int]string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, "meta.json"))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var meta struct {
+			Parts map[int]struct {
+				Sha256 string `json:"sha256"`
+			} `json:"parts"`
+		}
+		if err = json.Unmarshal(b, &meta); err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[int]string, len(meta.Parts))
+		for idx, p := range meta.Parts {
+			out[idx] = p.Sha256
+		}
+		return out
+	}
+	check := func(want map[int]int) {
+		t.Helper()
+		meta := readMeta(filepath.Join(root, fileID))
+		if len(meta) != len(want) {
+			t.Fatalf("meta.json has %d parts, want %d", len(meta), len(want))
+		}
+		for idx := 0; idx < parts; idx++ {
+			data, err := os.ReadFile(filepath.Join(root, fileID, fmt.Sprintf("%d.part", idx)))
+			gen, ok := want[idx]
+			if !ok {
+				if !os.IsNotExist(err) {
+					t.Fatalf("part %d must be deleted", idx)
+				}
+				continue
+			}
+			sum := sha256.Sum256([]byte(body(idx, gen)))
+			if err != nil || string(data) != body(idx, gen) || meta[idx] != hex.EncodeToString(sum[:]) {
+				t.Fatalf("part %d: %q %v, meta sha %s", idx, data, err, meta[idx])
+			}
+		}
+	}
+	// checkOrphan проверяет, что каталог брошенной загрузки либо удалён, либо согласован:
+	// каждая часть на диске описана в meta.json с верной суммой, и наоборот.
+	checkOrphan := func() {
+		t.Helper()
+		dir := filepath.Join(root, orphanFileID)
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta := readMeta(dir)
+		onDisk := 0
+		for _, e := range entries {
+			name, ok := strings.CutSuffix(e.Name(), ".part")
+			if !ok {
+				continue
+			}
+			idx, _ := strconv.Atoi(name)
+			data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			sum := sha256.Sum256(data)
+			if err != nil || meta[idx] != hex.EncodeToString(sum[:]) {
+				t.Fatalf("orphan part %d: %v, meta sha %q", idx, err, meta[idx])
+			}
+			onDisk++
+		}
+		if onDisk != len(meta) {
+			t.Fatalf("orphan meta.json has %d parts, %d on disk", len(meta), onDisk)
+		}
+	}
+
+	// Все части одного файла приходят одновременно: ни одна запись в meta.json не теряется.
+	want := map[int]int{}
+	var ops []func() error
+	for idx := 0; idx < parts; idx++ {
+		idx := idx
+		want[idx] = 1
+		ops = append(ops, func() error { return send(http.MethodPut, fileID, idx, 1, parts) })
+	}
+	run(ops...)
+	check(want)
+
+	// Удаления половины частей идут вперемешку с перезаписью другой половины.
+	ops = ops[:0]
+	for idx := 0; idx < parts; idx++ {
+		idx := idx
+		if idx%2 == 0 {
+			delete(want, idx)
+			ops = append(ops, func() error { return send(http.MethodDelete, fileID, idx, 0, 0) })
+		} else {
+			want[idx] = 2
+			ops = append(ops, func() error { return send(http.MethodPut, fileID, idx, 2, parts) })
+		}
+	}
+	run(ops...)
+	check(want)
+
+	// Удаление последних частей одновременно с записью новых не теряет новые части.
+	ops = ops[:0]
+	for idx := 0; idx < parts; idx++ {
+		idx := idx
+		if idx%2 == 0 {
+			want[idx] = 3
+			ops = append(ops, func() error { return send(http.MethodPut, fileID, idx, 3, parts) })
+		} else {
+			delete(want, idx)
+			ops = append(ops, func() error { return send(http.MethodDelete, fileID, idx, 0, 0) })
+		}
+	}
+	run(ops...)
+	check(want)
+
+	// GC удаляет брошенную загрузку (total 0, meta.json в прошлом), пока в неё пишутся части.
+	// Запись в удалённый каталог может завершиться ошибкой, но каталог не остаётся полуразобранным.
+	reaped := 0
+	for round := 1; round <= 10; round++ {
+		ops = ops[:0]
+		for idx := 0; idx < parts; idx++ {
+			idx, gen := idx, round
+			ops = append(ops, func() error {
+				_ = send(http.MethodPut, orphanFileID, idx, gen, 0)
+				return nil
+			})
+		}
+		reaped += run(ops...)
+		checkOrphan()
+		check(want)
+	}
+	if reaped == 0 {
+		t.Fatalf("GC never reaped the orphan upload while parts were written")
+	}
+}
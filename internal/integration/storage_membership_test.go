@@ -0,0 +1,189 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+type storageMember struct {
+	URL        string `json:"url"`
+	State      string `json:"state"`
+	Membership string `json:"membership"`
+}
+
+func TestStorageMembership_DrainRemoveAndPersist(t *testing.T) {
+	stable := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(stable.Close)
+	leaving := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(leaving.Close)
+
+	cfg := &config.Config{
+		ListenAddr:             ":0",
+		MetaDSN:                "memory://" + t.Name(),
+		Storages:               []string{stable.URL, leaving.URL},
+		HealthCheckIntervalSec: 1,
+		AdminAPIKey:            testAdminKey,
+	}
+	// Две реплики REST над общими метаданными.
+	startRest := func() string {
+		t.Helper()
+		handler, srv, err := resthttp.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("new rest server: %v", err)
+		}
+		t.Cleanup(srv.Close)
+		rest := httptest.NewServer(handler)
+		t.Cleanup(rest.Close)
+		return rest.URL
+	}
+	restA, restB := startRest(), startRest()
+
+	members := func(base string) map[string]storageMember {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, base+"/admin/storages", nil)
+		req.Header.Set("X-API-Key", testAdminKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Storages []storageMember `json:"storages"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]storageMember, len(body.Storages))
+		for _, s := range body.Storages {
+			out[s.URL] = s
+		}
+		return out
+	}
+	status := func(method, u, body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, u, strings.NewReader(body))
+		req.Header.Set("X-API-Key", testAdminKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	partStorages := func(base, fileID string) []string {
+		t.Helper()
+		resp, err := http.Get(base + "/files/" + fileID + "/meta")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var meta fileMetaResponse
+		if err = json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, p := range meta.Parts {
+			out = append(out, p.Storages...)
+		}
+		return out
+	}
+
+	payload := bytes.Repeat([]byte("member!"), 900)
+	before, err := uploadFile(restA+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	onLeaving := false
+	for _, s := range partStorages(restA, before.FileID) {
+		onLeaving = onLeaving || s == leaving.URL
+	}
+	if !onLeaving {
+		t.Fatalf("file is not spread over both nodes")
+	}
+
+	// Некорректные адрес и состояние отклоняются, неизвестный узел не удаляется.
+	if code := status(http.MethodPost, restA+"/admin/storages", `{"storages":["ftp://node"]}`); code != http.StatusBadRequest {
+		t.Fatalf("invalid url: status %d", code)
+	}
+	if code := status(http.MethodPost, restA+"/admin/storages", `{"storages":["`+leaving.URL+`"],"state":"asleep"}`); code != http.StatusBadRequest {
+		t.Fatalf("invalid state: status %d", code)
+	}
+	if code := status(http.MethodDelete, restA+"/admin/storages", ""); code != http.StatusBadRequest {
+		t.Fatalf("delete without url: status %d", code)
+	}
+	if code := status(http.MethodDelete, restA+"/admin/storages?url="+url.QueryEscape("http://unknown:1"), ""); code != http.StatusNotFound {
+		t.Fatalf("delete unknown node: status %d", code)
+	}
+
+	// draining, выставленный через реплику A, сразу виден в общем составе, а до маршрутизатора B
+	// доходит фоновой синхронизацией.
+	if err = postAdminJSON(restA+"/admin/storages", map[string]any{"storages": []string{leaving.URL + "/"}, "state": "draining"}); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if m := members(restB)[leaving.URL]; m.Membership != "draining" {
+		t.Fatalf("replica B sees %+v", m)
+	}
+	usesLeaving := func(fileID string) bool {
+		for _, s := range partStorages(restB, fileID) {
+			if s == leaving.URL {
+				return true
+			}
+		}
+		return false
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+		res, err := uploadFile(restB+"/files", payload)
+		if err != nil {
+			t.Fatalf("upload while draining: %v", err)
+		}
+		if !usesLeaving(res.FileID) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("replica B still writes to a draining node")
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		res, err := uploadFile(restB+"/files", payload)
+		if err != nil {
+			t.Fatalf("upload %d while draining: %v", i, err)
+		}
+		if usesLeaving(res.FileID) {
+			t.Fatalf("upload %d placed a part on a draining node", i)
+		}
+	}
+	// Уже записанные на draining-узел части читаются.
+	got, err := downloadFile(restB + "/files/" + before.FileID)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("download from draining node: %v", err)
+	}
+
+	if code := status(http.MethodDelete, restA+"/admin/storages?url="+url.QueryEscape(leaving.URL), ""); code != http.StatusNoContent {
+		t.Fatalf("delete node: status %d", code)
+	}
+	list := members(restA)
+	if _, ok := list[leaving.URL]; ok || len(list) != 1 {
+		t.Fatalf("removed node is still listed: %+v", list)
+	}
+	if m := list[stable.URL]; m.Membership != "active" || m.State == "" {
+		t.Fatalf("stable node: %+v", m)
+	}
+
+	// После перезапуска состав берётся из метаданных: узлы из конфига удалённый узел не возвращают.
+	restC := startRest()
+	list = members(restC)
+	if _, ok := list[leaving.URL]; ok || len(list) != 1 {
+		t.Fatalf("membership after restart: %+v", list)
+	}
+}
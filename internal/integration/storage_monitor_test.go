@@ -0,0 +1,163 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+type storageStatus struct {
+	URL       string `json:"url"`
+	State     string `json:"state"`
+	LatencyMs int64  `json:"latency_ms"`
+	FreeBytes int64  `json:"free_bytes"`
+	UsedBytes int64  `json:"used_bytes"`
+	Error     string `json:"error"`
+}
+
+func TestHealthMonitor_CachedRouting(t *testing.T) {
+	healthy := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(healthy.Close)
+
+	// Зависший узел: health не отвечает дольше таймаута проверки.
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	t.Cleanup(hung.Close)
+	t.Cleanup(func() { close(release) })
+
+	// Узел, чьё здоровье переключает тест; probes считает отданные ответы.
+	flappingStorage := storagehttp.New(t.TempDir())
+	var failing atomic.Bool
+	var probes atomic.Int64
+	flapping := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			defer probes.Add(1)
+			if failing.Load() {
+				http.Error(w, "disk failure", http.StatusInternalServerError)
+				return
+			}
+		}
+		flappingStorage.ServeHTTP(w, r)
+	}))
+	t.Cleanup(flapping.Close)
+
+	cfg := &config.Config{
+		ListenAddr:             ":0",
+		MetaDSN:                "memory://" + t.Name(),
+		Storages:               []string{healthy.URL, hung.URL, flapping.URL},
+		HealthCheckIntervalSec: 1,
+		AdminAPIKey:            testAdminKey,
+	}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	statuses := func() map[string]storageStatus {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, restSrv.URL+"/admin/storages", nil)
+		req.Header.Set("X-API-Key", testAdminKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Storages []storageStatus `json:"storages"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]storageStatus, len(body.Storages))
+		for _, s := range body.Storages {
+			out[s.URL] = s
+		}
+		return out
+	}
+	waitState := func(url, state string) storageStatus {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			st := statuses()[url]
+			if st.State == state {
+				return st
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: state %q, want %q", url, st.State, state)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitProbes := func(n int64) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for probes.Load() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("flapping node got %d probes, want %d", probes.Load(), n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		// Результат проверки применяется сразу после ответа узла.
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Первый опрос идёт в фоне: зависший узел помечается down по таймауту.
+	up := waitState(healthy.URL, "up")
+	if up.FreeBytes <= 0 {
+		t.Fatalf("capacity of a healthy node is unknown: %+v", up)
+	}
+	if st := waitState(hung.URL, "down"); st.Error == "" {
+		t.Fatalf("down node without error: %+v", st)
+	}
+	waitState(flapping.URL, "up")
+
+	// Загрузки выбирают узлы из кеша и не ждут зависший узел.
+	started := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err = uploadFile(restSrv.URL+"/files", bytes.Repeat([]byte("monitor!"), 600)); err != nil {
+			t.Fatalf("upload %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("uploads took %s with a hung node", elapsed)
+	}
+	// Занятое место обновляется следующим опросом.
+	for deadline := time.Now().Add(3 * time.Second); statuses()[healthy.URL].UsedBytes == 0; time.Sleep(20 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("used bytes are not refreshed")
+		}
+	}
+
+	// Гистерезис: один неудачный ответ не выводит узел из работы, два подряд — выводят.
+	failing.Store(true)
+	waitProbes(probes.Load() + 1)
+	if st := statuses()[flapping.URL]; st.State != "up" {
+		t.Fatalf("single failed probe changed state: %+v", st)
+	}
+	waitProbes(probes.Load() + 1)
+	waitState(flapping.URL, "degraded")
+
+	failing.Store(false)
+	waitProbes(probes.Load() + 1)
+	if st := statuses()[flapping.URL]; st.State != "degraded" {
+		t.Fatalf("single successful probe restored the node: %+v", st)
+	}
+	waitProbes(probes.Load() + 1)
+	waitState(flapping.URL, "up")
+}
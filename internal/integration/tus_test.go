@@ -0,0 +1,282 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+// brokenReader отдаёт n байт и затем обрывает поток, как упавший клиент.
+type brokenReader struct {
+	data []byte
+	n    int
+}
+
+func (b *brokenReader) Read(p []byte) (int, error) {
+	if b.n == 0 {
+		return 0, errors.New("client crashed")
+	}
+	n := copy(p, b.data[:min(len(b.data), b.n)])
+	b.data, b.n = b.data[n:], b.n-n
+	return n, nil
+}
+
+func TestTusUpload_ResumesAfterInterruption(t *testing.T) {
+	node := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(node.Close)
+
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{node.URL}}
+	handler, srv, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	tus := func(method, url string, body io.Reader, header ...string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, url, body)
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+	offsetOf := func(loc string) int64 {
+		t.Helper()
+		resp := tus(http.MethodHead, restSrv.URL+loc, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("head: %s", resp.Status)
+		}
+		n, _ := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+		return n
+	}
+	patch := func(loc string, offset int64, body io.Reader, header ...string) *http.Response {
+		return tus(http.MethodPatch, restSrv.URL+loc, body, append([]string{
+			"Content-Type", "application/offset+octet-stream",
+			"Upload-Offset", strconv.FormatInt(offset, 10),
+		}, header...)...)
+	}
+
+	resp := tus(http.MethodOptions, restSrv.URL+"/tus/", nil)
+	if resp.Header.Get("Tus-Extension") != "creation,termination,checksum,expiration" {
+		t.Fatalf("unexpected extensions %q", resp.Header.Get("Tus-Extension"))
+	}
+	req, _ := http.NewRequest(http.MethodPost, restSrv.URL+"/tus/", nil)
+	req.Header.Set("Upload-Length", "10")
+	if resp, err = http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("request without Tus-Resumable must get 412: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	payload := bytes.Repeat([]byte("tus-data"), 16000)
+	resp = tus(http.MethodPost, restSrv.URL+"/tus/", nil,
+		"Upload-Length", strconv.Itoa(len(payload)),
+		"Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte("video.mp4"))+",private")
+	loc := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusCreated || loc == "" {
+		t.Fatalf("create: %s", resp.Status)
+	}
+	if expires, err := http.ParseTime(resp.Header.Get("Upload-Expires")); err != nil || !expires.After(time.Now()) {
+		t.Fatalf("create: Upload-Expires %q", resp.Header.Get("Upload-Expires"))
+	}
+
+	if resp = patch(loc, 100, bytes.NewReader(payload)); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("wrong offset: %s, want 409", resp.Status)
+	}
+	if resp = patch(loc, 0, bytes.NewReader(payload[:30000])); resp.StatusCode != http.StatusNoContent || resp.Header.Get("Upload-Offset") != "30000" {
+		t.Fatalf("first patch: %s offset %q", resp.Status, resp.Header.Get("Upload-Offset"))
+	}
+
+	// Клиент падает посреди PATCH: дошедшие до сервера байты остаются за ним.
+	req, _ = http.NewRequest(http.MethodPatch, restSrv.URL+loc, &brokenReader{data: payload[30000:], n: 40000})
+	req.ContentLength = int64(len(payload) - 30000)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "30000")
+	if resp, err = http.DefaultClient.Do(req); err == nil {
+		_ = resp.Body.Close()
+		t.Fatalf("broken body must fail on the client")
+	}
+	offset := int64(30000)
+	for deadline := time.Now().Add(5 * time.Second); offset == 30000 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		offset = offsetOf(loc)
+	}
+	if offset <= 30000 || offset > 70000 {
+		t.Fatalf("offset after interrupted patch: %d, want (30000, 70000]", offset)
+	}
+
+	rest := payload[offset:]
+	wrong := sha256.Sum256([]byte("other"))
+	if resp = patch(loc, offset, bytes.NewReader(rest), "Upload-Checksum", "sha256 "+base64.StdEncoding.EncodeToString(wrong[:])); resp.StatusCode != 460 {
+		t.Fatalf("checksum mismatch: %s, want 460", resp.Status)
+	}
+	if got := offsetOf(loc); got != offset {
+		t.Fatalf("rejected patch moved offset to %d", got)
+	}
+	sum := sha256.Sum256(rest)
+	if resp = patch(loc, offset, bytes.NewReader(rest), "Upload-Checksum", "sha256 "+base64.StdEncoding.EncodeToString(sum[:])); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("final patch: %s", resp.Status)
+	}
+	if got := offsetOf(loc); got != int64(len(payload)) {
+		t.Fatalf("offset after completion: %d", got)
+	}
+
+	fileID := loc[len("/tus/"):]
+	got, err := downloadFile(restSrv.URL + "/files/" + fileID)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("download tus file: %v", err)
+	}
+	resp, err = http.Head(restSrv.URL + "/files/" + fileID)
+	if err != nil || resp.Header.Get("Content-Disposition") != `attachment; filename=video.mp4` {
+		t.Fatalf("file name from metadata: %v %q", err, resp.Header.Get("Content-Disposition"))
+	}
+
+	// HEAD отвечает только о tus-загрузках: о файле из POST /files — 404, о пустой tus-загрузке — смещение 0.
+	plain, err := uploadFile(restSrv.URL+"/files", []byte("not a tus upload"))
+	if err != nil {
+		t.Fatalf("plain upload: %v", err)
+	}
+	if resp = tus(http.MethodHead, restSrv.URL+"/tus/"+plain.FileID, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("head of a plain file: %s, want 404", resp.Status)
+	}
+	resp = tus(http.MethodPost, restSrv.URL+"/tus/", nil, "Upload-Length", "0")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create empty: %s", resp.Status)
+	}
+	if got := offsetOf(resp.Header.Get("Location")); got != 0 {
+		t.Fatalf("offset of empty upload: %d", got)
+	}
+
+	// Termination.
+	resp = tus(http.MethodPost, restSrv.URL+"/tus/", nil, "Upload-Length", "10")
+	loc = resp.Header.Get("Location")
+	if resp = tus(http.MethodDelete, restSrv.URL+loc, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("terminate: %s", resp.Status)
+	}
+	if resp = tus(http.MethodHead, restSrv.URL+loc, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("head after terminate: %s", resp.Status)
+	}
+}
+
+func TestTusUpload_PatchIsExclusiveAcrossReplicas(t *testing.T) {
+	node := httptest.NewServer(storagehttp.New(t.TempDir()))
+	t.Cleanup(node.Close)
+
+	// Две реплики REST над общим хранилищем метаданных.
+	cfg := &config.Config{ListenAddr: ":0", MetaDSN: "memory://" + t.Name(), Storages: []string{node.URL}, MaxUploadPartBytes: 1000}
+	replicas := make([]string, 2)
+	for i := range replicas {
+		handler, srv, err := resthttp.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("new rest server: %v", err)
+		}
+		t.Cleanup(srv.Close)
+		restSrv := httptest.NewServer(handler)
+		t.Cleanup(restSrv.Close)
+		replicas[i] = restSrv.URL
+	}
+
+	tus := func(method, url string, body io.Reader, header ...string) (*http.Response, error) {
+		req, _ := http.NewRequest(method, url, body)
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		_ = resp.Body.Close()
+		return resp, nil
+	}
+	offsetOf := func(url string) int64 {
+		t.Helper()
+		resp, err := tus(http.MethodHead, url, nil)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("head: %v", err)
+		}
+		n, _ := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+		return n
+	}
+
+	payload := bytes.Repeat([]byte("0123456789"), 500)
+	resp, err := tus(http.MethodPost, replicas[0]+"/tus/", nil, "Upload-Length", strconv.Itoa(len(payload)))
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %v", err)
+	}
+	loc := resp.Header.Get("Location")
+
+	// Первая реплика принимает PATCH, тело которого ещё не дошло целиком.
+	body, pw := io.Pipe()
+	t.Cleanup(func() { _ = pw.CloseWithError(errors.New("test finished")) })
+	first := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodPatch, replicas[0]+loc, body)
+		req.ContentLength = int64(len(payload))
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+		req.Header.Set("Upload-Offset", "0")
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusNoContent {
+				err = errors.New(resp.Status)
+			}
+		}
+		first <- err
+	}()
+	if _, err = pw.Write(payload[:2500]); err != nil {
+		t.Fatalf("write first body: %v", err)
+	}
+	var offset int64
+	for deadline := time.Now().Add(5 * time.Second); offset == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		offset = offsetOf(replicas[1] + loc)
+	}
+	if offset == 0 {
+		t.Fatalf("first patch stored nothing")
+	}
+
+	// Вторая реплика видит то же смещение, но не должна дописывать сессию параллельно.
+	resp, err = tus(http.MethodPatch, replicas[1]+loc, bytes.NewReader(payload[offset:]),
+		"Content-Type", "application/offset+octet-stream",
+		"Upload-Offset", strconv.FormatInt(offset, 10))
+	if err != nil {
+		t.Fatalf("concurrent patch on another replica: %v", err)
+	}
+	if resp.StatusCode != http.StatusLocked {
+		t.Fatalf("concurrent patch on another replica: %s, want 423", resp.Status)
+	}
+
+	if _, err = pw.Write(payload[2500:]); err != nil {
+		t.Fatalf("write first body: %v", err)
+	}
+	_ = pw.Close()
+	if err = <-first; err != nil {
+		t.Fatalf("first patch: %v", err)
+	}
+
+	got, err := downloadFile(replicas[1] + "/files/" + loc[len("/tus/"):])
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("download after exclusive patches: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestUploadWithoutContentLength(t *testing.T) {
+	const partSize = 4096
+
+	dirs := []string{t.TempDir(), t.TempDir()}
+	var storages []string
+	for _, d := range dirs {
+		s := httptest.NewServer(storagehttp.New(d))
+		t.Cleanup(s.Close)
+		storages = append(storages, s.URL)
+	}
+
+	cfg := &config.Config{
+		ListenAddr:          ":0",
+		MetaDSN:             "memory://" + t.Name(),
+		Storages:            storages,
+		StreamPartSizeBytes: partSize,
+	}
+	handler, _, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	cases := []struct {
+		size      int
+		wantParts int
+	}{
+		{size: 3*partSize + 100, wantParts: 4},
+		{size: 2 * partSize, wantParts: 2},
+		{size: 0, wantParts: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%d bytes", tc.size), func(t *testing.T) {
+			payload := bytes.Repeat([]byte{0x5A}, tc.size)
+			res, err := uploadChunked(restSrv.URL+"/files", payload)
+			if err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+			if res.Size != int64(tc.size) || res.Parts != tc.wantParts {
+				t.Fatalf("upload result %+v, want size %d parts %d", res, tc.size, tc.wantParts)
+			}
+
+			got, err := downloadFile(restSrv.URL + "/files/" + res.FileID)
+			if err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("downloaded %d bytes, want %d", len(got), len(payload))
+			}
+
+			// На каждом сторадже meta.json должен знать итоговое число частей.
+			for _, d := range dirs {
+				raw, err := os.ReadFile(filepath.Join(d, res.FileID, "meta.json"))
+				if os.IsNotExist(err) {
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				var fm struct {
+					TotalParts int `json:"total_parts"`
+				}
+				if err = json.Unmarshal(raw, &fm); err != nil {
+					t.Fatal(err)
+				}
+				if fm.TotalParts != tc.wantParts {
+					t.Fatalf("storage meta total_parts %d, want %d", fm.TotalParts, tc.wantParts)
+				}
+			}
+		})
+	}
+}
+
+// uploadChunked отправляет тело через pipe, поэтому клиент не знает длину и использует chunked encoding.
+func uploadChunked(url string, data []byte) (uploadResponse, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := pw.Write(data)
+		_ = pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, url, pr)
+	if err != nil {
+		return uploadResponse{}, err
+	}
+	req.ContentLength = -1
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return uploadResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return uploadResponse{}, fmt.Errorf("unexpected status %s: %s", resp.Status, body)
+	}
+
+	var out uploadResponse
+	return out, json.NewDecoder(resp.Body).Decode(&out)
+}
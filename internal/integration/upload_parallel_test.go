@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/app/resthttp"
+	"github.com/sir_venger/s3_lite/internal/app/storagehttp"
+	"github.com/sir_venger/s3_lite/internal/config"
+)
+
+func TestUploadWhole_SendsPartsInParallel(t *testing.T) {
+	const concurrency = 3
+
+	spoolDir := t.TempDir()
+	var inFlight, maxInFlight atomic.Int32
+	var storages []string
+	for i := 0; i < 3; i++ {
+		node := storagehttp.New(t.TempDir())
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					m := maxInFlight.Load()
+					if n <= m || maxInFlight.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+			node.ServeHTTP(w, r)
+		}))
+		t.Cleanup(s.Close)
+		storages = append(storages, s.URL)
+	}
+
+	cfg := &config.Config{
+		ListenAddr:        ":0",
+		MetaDSN:           "memory://" + t.Name(),
+		Storages:          storages,
+		UploadConcurrency: concurrency,
+		// Крошечный бюджет памяти: части должны уходить во временные файлы в SpoolDir.
+		UploadMemoryLimitBytes: 1024,
+		SpoolDir:               spoolDir,
+	}
+	handler, _, err := resthttp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("new rest server: %v", err)
+	}
+	restSrv := httptest.NewServer(handler)
+	t.Cleanup(restSrv.Close)
+
+	payload := make([]byte, 600_000)
+	for i := range payload {
+		payload[i] = byte(i % 251)
+	}
+	res, err := uploadFile(restSrv.URL+"/files", payload)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if got := maxInFlight.Load(); got < 2 || got > concurrency {
+		t.Fatalf("max parallel part uploads %d, want 2..%d", got, concurrency)
+	}
+	if left, _ := os.ReadDir(spoolDir); len(left) != 0 {
+		t.Fatalf("spool files were not cleaned up: %d left", len(left))
+	}
+
+	got, err := downloadFile(restSrv.URL + "/files/" + res.FileID)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("downloaded data mismatch")
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// Permission — право, которое API-ключ даёт на операции REST.
+type Permission string
+
+const (
+	PermRead   Permission = "read"
+	PermWrite  Permission = "write"
+	PermDelete Permission = "delete"
+	// PermAdmin открывает /admin/* и подразумевает все остальные права.
+	PermAdmin Permission = "admin"
+)
+
+// ValidPermission проверяет, что право известно.
+func ValidPermission(p Permission) bool {
+	switch p {
+	case PermRead, PermWrite, PermDelete, PermAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey — ключ доступа к REST. Секрет не хранится: только его sha256.
+type APIKey struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	SecretHash  string       `json:"secret_hash"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// Allows сообщает, даёт ли ключ право p.
+func (k APIKey) Allows(p Permission) bool {
+	return slices.Contains(k.Permissions, PermAdmin) || slices.Contains(k.Permissions, p)
+}
+
+// HashSecret — хеш секрета ключа в том виде, в котором он хранится в метаданных.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxObjectKeyLength — предельная длина ключа объекта в байтах (как в S3).
+const MaxObjectKeyLength = 1024
+
+// bucketName — допустимые имена бакетов: 3–63 символа из строчных латинских букв, цифр, точек и дефисов.
+var bucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// Bucket — пространство имён объектов: ключи уникальны в пределах бакета.
+type Bucket struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidBucketName проверяет имя бакета.
+func ValidBucketName(name string) bool {
+	return bucketName.MatchString(name)
+}
+
+// ValidObjectKey проверяет ключ объекта: непустая строка UTF-8 не длиннее MaxObjectKeyLength.
+func ValidObjectKey(key string) bool {
+	return key != "" && len(key) <= MaxObjectKeyLength && utf8.ValidString(key)
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// PendingDeletion — часть файла, которую не удалось удалить со стоража сразу (узел был недоступен).
+type PendingDeletion struct {
+	FileID    string    `json:"file_id"`
+	Index     int       `json:"index"`
+	Storage   string    `json:"storage"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Key возвращает ключ, однозначно определяющий часть на конкретном сторадже.
+func (d PendingDeletion) Key() string {
+	return d.FileID + "\x00" + d.Storage + "\x00" + strconv.Itoa(d.Index)
+}
@@ -6,4 +6,27 @@ var (
 	ErrNotFound   = errors.New("file not found")
 	ErrIncomplete = errors.New("file incomplete")
 	ErrNoStorage  = errors.New("no storage ready")
+	ErrBadCursor  = errors.New("invalid list cursor")
+	ErrCorrupted  = errors.New("part checksum mismatch")
+
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrInvalidPart    = errors.New("invalid upload part")
+	ErrTooLarge       = errors.New("part too large")
+	ErrChecksum       = errors.New("checksum mismatch")
+	ErrOffset         = errors.New("upload offset mismatch")
+	ErrUploadLocked   = errors.New("upload is locked by another request")
+
+	ErrBucketNotFound = errors.New("bucket not found")
+	ErrBucketExists   = errors.New("bucket already exists")
+	ErrBucketNotEmpty = errors.New("bucket is not empty")
+	ErrInvalidName    = errors.New("invalid bucket name or object key")
+
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrUnauthorized = errors.New("missing or invalid api key")
+	ErrForbidden    = errors.New("api key lacks permission")
+	// ErrInvalidPermission — пустой или неизвестный список прав нового ключа.
+	ErrInvalidPermission = errors.New("invalid api key permission")
+
+	ErrStorageNotFound = errors.New("storage not found")
+	ErrInvalidStorage  = errors.New("invalid storage url or state")
 )
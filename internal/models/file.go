@@ -1,11 +1,84 @@
 package models
 
-// Part описывает одну часть файла, лежащую в узле хранения.
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Part описывает одну часть файла, лежащую в узлах хранения.
 type Part struct {
+	Index  int    `json:"index"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+	// Storage — основной узел части (совпадает с Storages[0]); единственный источник для записей без реплик.
+	Storage string `json:"storage"`
+	// Storages — все узлы, на которых лежат копии части.
+	Storages []string `json:"storages,omitempty"`
+	// ShardSize — размер одного шарда, если часть хранится в режиме erasure coding.
+	ShardSize int64 `json:"shard_size,omitempty"`
+	// Shards — шарды данных и чётности части по порядку кодирования (только для erasure coding).
+	Shards []Shard `json:"shards,omitempty"`
+}
+
+// Clone возвращает копию части, не разделяющую срезы с исходной.
+func (p Part) Clone() Part {
+	p.Storages = append([]string(nil), p.Storages...)
+	p.Shards = append([]Shard(nil), p.Shards...)
+	return p
+}
+
+// Shard — один шард закодированной части на сторадже.
+type Shard struct {
+	// Index — номер, под которым шард хранится на узле как часть файла.
 	Index   int    `json:"index"`
-	Size    int64  `json:"size"`
-	Sha256  string `json:"sha256"`
 	Storage string `json:"storage"`
+	Sha256  string `json:"sha256"`
+}
+
+// ErasureLayout описывает схему Reed–Solomon, которой закодированы части файла.
+type ErasureLayout struct {
+	DataShards   int `json:"data_shards"`
+	ParityShards int `json:"parity_shards"`
+}
+
+// Placement — физический объект на сторадже: часть файла либо её шард.
+type Placement struct {
+	Storage string
+	Index   int
+}
+
+// Placements перечисляет все объекты на стораджах, из которых состоит часть.
+func (p Part) Placements() []Placement {
+	if len(p.Shards) > 0 {
+		out := make([]Placement, 0, len(p.Shards))
+		for _, sh := range p.Shards {
+			// Шард без узла не записался при загрузке и восстанавливается из остальных.
+			if sh.Storage != "" {
+				out = append(out, Placement{Storage: sh.Storage, Index: sh.Index})
+			}
+		}
+		return out
+	}
+
+	locations := p.Locations()
+	out := make([]Placement, 0, len(locations))
+	for _, storage := range locations {
+		out = append(out, Placement{Storage: storage, Index: p.Index})
+	}
+	return out
+}
+
+// Locations возвращает узлы с копиями части в порядке предпочтения для чтения.
+func (p Part) Locations() []string {
+	if len(p.Storages) > 0 {
+		return p.Storages
+	}
+	if p.Storage == "" {
+		return nil
+	}
+	return []string{p.Storage}
 }
 
 // File содержит агрегированные метаданные о всех частях файла.
@@ -15,6 +88,14 @@ type File struct {
 	Size       int64        `json:"size"`
 	TotalParts int          `json:"total_parts"`
 	Parts      map[int]Part `json:"parts"`
+	CreatedAt  time.Time    `json:"created_at"`
+	// Erasure задан, если части файла закодированы шардами вместо реплик.
+	Erasure *ErasureLayout `json:"erasure,omitempty"`
+	// Bucket и Key заданы у объектов бакета; пара уникальна, имя объекта совпадает с ключом.
+	Bucket string `json:"bucket,omitempty"`
+	Key    string `json:"key,omitempty"`
+	// Tus задан у файлов, собранных tus-загрузкой (POST /tus): только о них отвечает HEAD /tus/{id}.
+	Tus bool `json:"tus,omitempty"`
 }
 
 // Clone возвращает копию структуры, чтобы не делиться внутренними картами.
@@ -25,9 +106,29 @@ func (f File) Clone() File {
 		Size:       f.Size,
 		TotalParts: f.TotalParts,
 		Parts:      map[int]Part{},
+		CreatedAt:  f.CreatedAt,
+		Bucket:     f.Bucket,
+		Key:        f.Key,
+		Tus:        f.Tus,
+	}
+	if f.Erasure != nil {
+		layout := *f.Erasure
+		out.Erasure = &layout
 	}
 	for idx, part := range f.Parts {
-		out.Parts[idx] = part
+		out.Parts[idx] = part.Clone()
 	}
 	return out
 }
+
+// ETag возвращает сильный валидатор содержимого, вычисленный из контрольных сумм частей.
+func (f File) ETag() string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(f.Size, 10)))
+	for idx := 0; idx < f.TotalParts; idx++ {
+		h.Write([]byte{0})
+		h.Write([]byte(f.Parts[idx].Sha256))
+	}
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// ListSort задаёт поле сортировки при перечислении файлов.
+type ListSort string
+
+const (
+	SortByCreated ListSort = "created"
+	SortBySize    ListSort = "size"
+	// SortByName упорядочивает по имени побайтово (как ключи S3).
+	SortByName ListSort = "name"
+)
+
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// ListFilter описывает запрос страницы списка файлов.
+type ListFilter struct {
+	// Bucket ограничивает выборку объектами бакета; Prefix, After и сортировка по имени
+	// тогда относятся к ключу объекта.
+	Bucket string
+	// Prefix отбирает файлы, имя которых начинается с указанной строки.
+	Prefix string
+	// Limit — максимальный размер страницы.
+	Limit int
+	// Cursor — непрозрачный курсор из FileList.NextCursor предыдущей страницы.
+	Cursor string
+	// After отбирает файлы с именем строго больше указанного (только для SortByName).
+	After  string
+	SortBy ListSort
+	Desc   bool
+}
+
+// Normalize подставляет значения по умолчанию и ограничивает размер страницы.
+func (f ListFilter) Normalize() ListFilter {
+	if f.Limit <= 0 {
+		f.Limit = DefaultListLimit
+	}
+	if f.Limit > MaxListLimit {
+		f.Limit = MaxListLimit
+	}
+	if f.SortBy == "" {
+		f.SortBy = SortByCreated
+	}
+
+	return f
+}
+
+// FileList — страница списка файлов. Пустой NextCursor означает последнюю страницу.
+type FileList struct {
+	Files      []File
+	NextCursor string
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// MaxUploadParts — наибольший номер части в составной загрузке (номера начинаются с 1).
+const MaxUploadParts = 10000
+
+// Upload описывает сессию составной (multipart) загрузки.
+// Часть с номером n хранится под индексом n-1 — тем же, что получит в итоговом файле.
+type Upload struct {
+	ID        string    `json:"upload_id"`
+	Name      string    `json:"file_name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt — срок жизни сессии: после него сессия считается брошенной и удаляется вместе с частями.
+	ExpiresAt time.Time `json:"expires_at"`
+	// Length — объявленный при создании размер файла (tus); 0 — размер определится при завершении.
+	Length int64 `json:"length,omitempty"`
+	// Metadata — исходная строка Upload-Metadata клиента tus.
+	Metadata string `json:"metadata,omitempty"`
+	// Tus задан у сессий, созданных через POST /tus; завершённый файл наследует признак.
+	Tus bool `json:"tus,omitempty"`
+	// Erasure фиксирует схему кодирования на момент создания сессии, чтобы все части были совместимы.
+	Erasure *ErasureLayout `json:"erasure,omitempty"`
+	// Bucket и Key задают объект, который заменит завершённая сессия (пусто — обычный файл).
+	Bucket string       `json:"bucket,omitempty"`
+	Key    string       `json:"key,omitempty"`
+	Parts  map[int]Part `json:"parts"`
+}
+
+// UploadSpec — параметры новой сессии загрузки.
+type UploadSpec struct {
+	Name     string
+	Length   int64
+	Metadata string
+	Tus      bool
+	// Bucket и Key — необязательный адрес объекта; Name тогда не используется.
+	Bucket string
+	Key    string
+}
+
+// Received возвращает число принятых байт — смещение, с которого tus-клиент продолжает загрузку.
+func (u Upload) Received() int64 {
+	var n int64
+	for _, p := range u.Parts {
+		n += p.Size
+	}
+	return n
+}
+
+// Expired сообщает, истёк ли срок сессии к моменту now. Сессии без срока не истекают.
+func (u Upload) Expired(now time.Time) bool {
+	return !u.ExpiresAt.IsZero() && !now.Before(u.ExpiresAt)
+}
+
+// Clone возвращает копию сессии, не разделяющую карты и срезы с исходной.
+func (u Upload) Clone() Upload {
+	parts := make(map[int]Part, len(u.Parts))
+	for idx, part := range u.Parts {
+		parts[idx] = part.Clone()
+	}
+	u.Parts = parts
+	if u.Erasure != nil {
+		layout := *u.Erasure
+		u.Erasure = &layout
+	}
+
+	return u
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"net/url"
+	"time"
+)
+
+// NodeState — состояние стоража в составе кластера.
+type NodeState string
+
+const (
+	// NodeActive — узел принимает новые части.
+	NodeActive NodeState = "active"
+	// NodeDraining — узел отдаёт записанные части, но новых не получает: так его готовят к выводу из кластера.
+	NodeDraining NodeState = "draining"
+)
+
+// StorageNode — сторадж в составе кластера.
+type StorageNode struct {
+	URL       string    `json:"url"`
+	State     NodeState `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ValidNodeState проверяет, что состояние известно.
+func ValidNodeState(s NodeState) bool {
+	return s == NodeActive || s == NodeDraining
+}
+
+// ValidStorageURL проверяет, что адрес стоража — абсолютный http(s) URL без query и фрагмента.
+func ValidStorageURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.RawQuery == "" && u.Fragment == ""
+}
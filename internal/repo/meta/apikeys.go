@@ -0,0 +1,106 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const apiKeysTable = "api_keys"
+
+// CreateAPIKey сохраняет новый API-ключ.
+func (s *PGStore) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Insert(apiKeysTable).
+		Columns("id", "name", "secret_hash", "permissions", "created_at").
+		Values(key.ID, key.Name, key.SecretHash, permissionStrings(key.Permissions), key.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build api key insert: %w", err)
+	}
+
+	if _, err = s.pool.Exec(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("exec api key insert: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKey возвращает ключ по идентификатору.
+func (s *PGStore) GetAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	keys, err := s.selectAPIKeys(ctx, sq.Eq{"id": id})
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if len(keys) == 0 {
+		return models.APIKey{}, models.ErrKeyNotFound
+	}
+
+	return keys[0], nil
+}
+
+// ListAPIKeys возвращает все ключи в порядке создания.
+func (s *PGStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.selectAPIKeys(ctx, nil)
+}
+
+// DeleteAPIKey отзывает ключ.
+func (s *PGStore) DeleteAPIKey(ctx context.Context, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM `+apiKeysTable+` WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("exec api key delete: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrKeyNotFound
+	}
+
+	return nil
+}
+
+func (s *PGStore) selectAPIKeys(ctx context.Context, where sq.Sqlizer) ([]models.APIKey, error) {
+	q := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("id", "name", "secret_hash", "permissions", "created_at").
+		From(apiKeysTable).
+		OrderBy("created_at", "id")
+	if where != nil {
+		q = q.Where(where)
+	}
+	sqlStr, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build api key select: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var out []models.APIKey
+	for rows.Next() {
+		var (
+			key   models.APIKey
+			perms []string
+		)
+		if err = rows.Scan(&key.ID, &key.Name, &key.SecretHash, &perms, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		for _, p := range perms {
+			key.Permissions = append(key.Permissions, models.Permission(p))
+		}
+		out = append(out, key)
+	}
+
+	return out, rows.Err()
+}
+
+func permissionStrings(perms []models.Permission) []string {
+	out := make([]string, 0, len(perms))
+	for _, p := range perms {
+		out = append(out, string(p))
+	}
+
+	return out
+}
@@ -0,0 +1,711 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltScheme      = "bolt"
+	boltOpenTimeout = 5 * time.Second
+)
+
+var (
+	boltFilesBucket   = []byte(filesMetaTable)
+	boltPendingBucket = []byte(pendingDeletionsTable)
+	boltUploadsBucket = []byte(uploadsTable)
+	// boltUploadLocksBucket — аренды дозаписи в сессии по идентификатору сессии.
+	boltUploadLocksBucket = []byte("upload_locks")
+	boltBucketsBucket     = []byte(bucketsTable)
+	// boltObjectsBucket — индекс objectKey(бакет, ключ) → идентификатор файла.
+	boltObjectsBucket = []byte("objects")
+	boltAPIKeysBucket = []byte(apiKeysTable)
+	boltNodesBucket   = []byte(storageNodesTable)
+)
+
+// BoltStore хранит метаданные во встроенной базе bbolt. Рассчитан на развёртывание на одном хосте.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var _ Store = (*BoltStore)(nil)
+
+func init() {
+	Register(boltScheme, func(_ context.Context, dsn string) (Store, error) {
+		return NewBoltStore(dsn)
+	})
+}
+
+// NewBoltStore открывает (или создаёт) файл базы по DSN вида bolt:///path/to/meta.db.
+func NewBoltStore(dsn string) (*BoltStore, error) {
+	path, err := boltPath(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	// Timeout защищает от вечного ожидания, если файл уже заблокирован другим процессом.
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt db %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltFilesBucket, boltPendingBucket, boltUploadsBucket, boltUploadLocksBucket, boltBucketsBucket, boltObjectsBucket, boltAPIKeysBucket, boltNodesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("init bolt buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Get возвращает описание файла по его идентификатору.
+func (s *BoltStore) Get(_ context.Context, id string) (models.File, error) {
+	if strings.TrimSpace(id) == "" {
+		return models.File{}, fmt.Errorf("file id is empty")
+	}
+
+	var file models.File
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltFilesBucket).Get([]byte(id))
+		if raw == nil {
+			return models.ErrNotFound
+		}
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return fmt.Errorf("unmarshal file: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.File{}, err
+	}
+
+	file.ID = id
+	if file.Parts == nil {
+		file.Parts = make(map[int]models.Part)
+	}
+
+	return file.Clone(), nil
+}
+
+// Save записывает (или обновляет) описание файла.
+func (s *BoltStore) Save(_ context.Context, file models.File) error {
+	if strings.TrimSpace(file.ID) == "" {
+		return fmt.Errorf("file id is empty")
+	}
+	if file.Parts == nil {
+		file.Parts = make(map[int]models.Part)
+	}
+
+	// Put перезаписывает существующий ключ — это и есть upsert. Время создания при этом сохраняется.
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltFilesBucket)
+
+		var prev models.File
+		if raw := b.Get([]byte(file.ID)); raw != nil && json.Unmarshal(raw, &prev) == nil {
+			file.CreatedAt = prev.CreatedAt
+		} else if file.CreatedAt.IsZero() {
+			file.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		}
+
+		raw, err := json.Marshal(file)
+		if err != nil {
+			return fmt.Errorf("marshal file: %w", err)
+		}
+		return b.Put([]byte(file.ID), raw)
+	})
+}
+
+// List возвращает страницу файлов с фильтром по префиксу имени.
+// Bolt не индексирует имена и размеры, поэтому выборка строится полным проходом по бакету.
+func (s *BoltStore) List(_ context.Context, f models.ListFilter) (models.FileList, error) {
+	var all []models.File
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltFilesBucket).ForEach(func(k, raw []byte) error {
+			var file models.File
+			if err := json.Unmarshal(raw, &file); err != nil {
+				return fmt.Errorf("unmarshal file %s: %w", k, err)
+			}
+			file.ID = string(k)
+			file.Parts = nil
+			all = append(all, file)
+			return nil
+		})
+	})
+	if err != nil {
+		return models.FileList{}, err
+	}
+
+	return listInMemory(all, f)
+}
+
+// Delete удаляет описание файла.
+func (s *BoltStore) Delete(_ context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("file id is empty")
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltFilesBucket)
+		raw := b.Get([]byte(id))
+		if raw == nil {
+			return models.ErrNotFound
+		}
+		var file models.File
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return fmt.Errorf("unmarshal file: %w", err)
+		}
+		if file.Bucket != "" {
+			if err := tx.Bucket(boltObjectsBucket).Delete([]byte(objectKey(file.Bucket, file.Key))); err != nil {
+				return err
+			}
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// AddPendingDeletions ставит части в очередь на повторное удаление.
+func (s *BoltStore) AddPendingDeletions(_ context.Context, items []models.PendingDeletion) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltPendingBucket)
+		for _, d := range items {
+			key := []byte(d.Key())
+			var prev models.PendingDeletion
+			if raw := b.Get(key); raw != nil && json.Unmarshal(raw, &prev) == nil {
+				d.CreatedAt = prev.CreatedAt
+			} else if d.CreatedAt.IsZero() {
+				d.CreatedAt = now
+			}
+
+			raw, err := json.Marshal(d)
+			if err != nil {
+				return fmt.Errorf("marshal pending deletion: %w", err)
+			}
+			if err = b.Put(key, raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListPendingDeletions возвращает до limit самых старых записей очереди.
+func (s *BoltStore) ListPendingDeletions(_ context.Context, limit int) ([]models.PendingDeletion, error) {
+	var out []models.PendingDeletion
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltPendingBucket).ForEach(func(_, raw []byte) error {
+			var d models.PendingDeletion
+			if err := json.Unmarshal(raw, &d); err != nil {
+				return fmt.Errorf("unmarshal pending deletion: %w", err)
+			}
+			out = append(out, d)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return oldestPending(out, limit), nil
+}
+
+// RemovePendingDeletion убирает часть из очереди.
+func (s *BoltStore) RemovePendingDeletion(_ context.Context, d models.PendingDeletion) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltPendingBucket).Delete([]byte(d.Key()))
+	})
+}
+
+// CreateUpload регистрирует новую сессию составной загрузки.
+func (s *BoltStore) CreateUpload(_ context.Context, upload models.Upload) error {
+	if strings.TrimSpace(upload.ID) == "" {
+		return fmt.Errorf("upload id is empty")
+	}
+	if upload.Parts == nil {
+		upload.Parts = make(map[int]models.Part)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltUploadsBucket)
+		if b.Get([]byte(upload.ID)) != nil {
+			return fmt.Errorf("upload %s already exists", upload.ID)
+		}
+		raw, err := json.Marshal(upload)
+		if err != nil {
+			return fmt.Errorf("marshal upload: %w", err)
+		}
+		return b.Put([]byte(upload.ID), raw)
+	})
+}
+
+// GetUpload возвращает сессию вместе с принятыми частями.
+func (s *BoltStore) GetUpload(_ context.Context, id string) (models.Upload, error) {
+	var upload models.Upload
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		upload, err = boltUpload(tx.Bucket(boltUploadsBucket), id)
+		return err
+	})
+
+	return upload, err
+}
+
+// ListExpiredUploads возвращает до limit сессий, срок которых истёк к моменту now.
+func (s *BoltStore) ListExpiredUploads(_ context.Context, now time.Time, limit int) ([]models.Upload, error) {
+	var out []models.Upload
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltUploadsBucket)
+		return b.ForEach(func(k, _ []byte) error {
+			upload, err := boltUpload(b, string(k))
+			if err != nil {
+				return err
+			}
+			if upload.Expired(now) {
+				out = append(out, upload)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return oldestExpired(out, limit), nil
+}
+
+// PutUploadPart записывает часть сессии и возвращает ту, которую она заменила.
+// Транзакция bbolt сериализует параллельные записи частей одной сессии.
+func (s *BoltStore) PutUploadPart(_ context.Context, id string, part models.Part) (models.Part, bool, error) {
+	var (
+		prev     models.Part
+		replaced bool
+	)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltUploadsBucket)
+		upload, err := boltUpload(b, id)
+		if err != nil {
+			return err
+		}
+
+		prev, replaced = upload.Parts[part.Index]
+		upload.Parts[part.Index] = part
+		raw, err := json.Marshal(upload)
+		if err != nil {
+			return fmt.Errorf("marshal upload: %w", err)
+		}
+		return b.Put([]byte(id), raw)
+	})
+	if err != nil {
+		return models.Part{}, false, err
+	}
+
+	return prev, replaced, nil
+}
+
+// DeleteUpload удаляет сессию.
+func (s *BoltStore) DeleteUpload(_ context.Context, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltUploadsBucket)
+		if b.Get([]byte(id)) == nil {
+			return models.ErrUploadNotFound
+		}
+		if err := tx.Bucket(boltUploadLocksBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// LockUpload берёт или продлевает на ttl аренду дозаписи в сессию для owner.
+// models.ErrUploadLocked, если действующая аренда принадлежит другому владельцу.
+func (s *BoltStore) LockUpload(_ context.Context, id, owner string, ttl time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltUploadsBucket).Get([]byte(id)) == nil {
+			return models.ErrUploadNotFound
+		}
+
+		b := tx.Bucket(boltUploadLocksBucket)
+		lease, err := boltUploadLease(b, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !lease.free(owner, now) {
+			return models.ErrUploadLocked
+		}
+
+		raw, err := json.Marshal(uploadLease{Owner: owner, Until: now.Add(ttl)})
+		if err != nil {
+			return fmt.Errorf("marshal upload lease: %w", err)
+		}
+		return b.Put([]byte(id), raw)
+	})
+}
+
+// UnlockUpload снимает аренду дозаписи, если она принадлежит owner.
+func (s *BoltStore) UnlockUpload(_ context.Context, id, owner string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltUploadLocksBucket)
+		lease, err := boltUploadLease(b, id)
+		if err != nil || lease.Owner != owner {
+			return err
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+func boltUploadLease(b *bolt.Bucket, id string) (uploadLease, error) {
+	var lease uploadLease
+	raw := b.Get([]byte(id))
+	if raw == nil {
+		return lease, nil
+	}
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		return lease, fmt.Errorf("unmarshal upload lease: %w", err)
+	}
+
+	return lease, nil
+}
+
+func boltUpload(b *bolt.Bucket, id string) (models.Upload, error) {
+	raw := b.Get([]byte(id))
+	if raw == nil {
+		return models.Upload{}, models.ErrUploadNotFound
+	}
+
+	var upload models.Upload
+	if err := json.Unmarshal(raw, &upload); err != nil {
+		return models.Upload{}, fmt.Errorf("unmarshal upload: %w", err)
+	}
+	upload.ID = id
+	if upload.Parts == nil {
+		upload.Parts = make(map[int]models.Part)
+	}
+
+	return upload, nil
+}
+
+// CreateBucket создаёт бакет; models.ErrBucketExists, если имя уже занято.
+func (s *BoltStore) CreateBucket(_ context.Context, name string) (models.Bucket, error) {
+	bucket := models.Bucket{Name: name, CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucketsBucket)
+		if b.Get([]byte(name)) != nil {
+			return models.ErrBucketExists
+		}
+		raw, err := json.Marshal(bucket)
+		if err != nil {
+			return fmt.Errorf("marshal bucket: %w", err)
+		}
+		return b.Put([]byte(name), raw)
+	})
+	if err != nil {
+		return models.Bucket{}, err
+	}
+
+	return bucket, nil
+}
+
+// GetBucket возвращает бакет по имени.
+func (s *BoltStore) GetBucket(_ context.Context, name string) (models.Bucket, error) {
+	var bucket models.Bucket
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		bucket, err = boltBucket(tx, name)
+		return err
+	})
+
+	return bucket, err
+}
+
+// ListBuckets возвращает все бакеты в порядке имён.
+func (s *BoltStore) ListBuckets(_ context.Context) ([]models.Bucket, error) {
+	var out []models.Bucket
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// Ключи bbolt упорядочены побайтово — дополнительная сортировка не нужна.
+		return tx.Bucket(boltBucketsBucket).ForEach(func(_, raw []byte) error {
+			var b models.Bucket
+			if err := json.Unmarshal(raw, &b); err != nil {
+				return fmt.Errorf("unmarshal bucket: %w", err)
+			}
+			out = append(out, b)
+			return nil
+		})
+	})
+
+	return out, err
+}
+
+// DeleteBucket удаляет пустой бакет.
+func (s *BoltStore) DeleteBucket(_ context.Context, name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := boltBucket(tx, name); err != nil {
+			return err
+		}
+		prefix := []byte(objectKey(name, ""))
+		if k, _ := tx.Bucket(boltObjectsBucket).Cursor().Seek(prefix); k != nil && bytes.HasPrefix(k, prefix) {
+			return models.ErrBucketNotEmpty
+		}
+		return tx.Bucket(boltBucketsBucket).Delete([]byte(name))
+	})
+}
+
+// GetObject возвращает объект бакета по ключу.
+func (s *BoltStore) GetObject(_ context.Context, bucket, key string) (models.File, error) {
+	var file models.File
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if _, err := boltBucket(tx, bucket); err != nil {
+			return err
+		}
+		id := tx.Bucket(boltObjectsBucket).Get([]byte(objectKey(bucket, key)))
+		if id == nil {
+			return models.ErrNotFound
+		}
+		raw := tx.Bucket(boltFilesBucket).Get(id)
+		if raw == nil {
+			return models.ErrNotFound
+		}
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return fmt.Errorf("unmarshal file: %w", err)
+		}
+		file.ID = string(id)
+		return nil
+	})
+	if err != nil {
+		return models.File{}, err
+	}
+
+	if file.Parts == nil {
+		file.Parts = make(map[int]models.Part)
+	}
+	return file.Clone(), nil
+}
+
+// PutObject записывает объект, заменяя прежний с тем же ключом, и возвращает заменённый.
+func (s *BoltStore) PutObject(_ context.Context, file models.File) (models.File, bool, error) {
+	if strings.TrimSpace(file.ID) == "" {
+		return models.File{}, false, fmt.Errorf("file id is empty")
+	}
+	if file.Parts == nil {
+		file.Parts = make(map[int]models.Part)
+	}
+	if file.CreatedAt.IsZero() {
+		file.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	}
+
+	var (
+		prev     models.File
+		replaced bool
+	)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := boltBucket(tx, file.Bucket); err != nil {
+			return err
+		}
+
+		files, objects := tx.Bucket(boltFilesBucket), tx.Bucket(boltObjectsBucket)
+		k := []byte(objectKey(file.Bucket, file.Key))
+		if id := objects.Get(k); id != nil {
+			if raw := files.Get(id); raw != nil {
+				if err := json.Unmarshal(raw, &prev); err != nil {
+					return fmt.Errorf("unmarshal file: %w", err)
+				}
+				prev.ID = string(id)
+				replaced = true
+				if err := files.Delete(id); err != nil {
+					return err
+				}
+			}
+		}
+
+		raw, err := json.Marshal(file)
+		if err != nil {
+			return fmt.Errorf("marshal file: %w", err)
+		}
+		if err = files.Put([]byte(file.ID), raw); err != nil {
+			return err
+		}
+		return objects.Put(k, []byte(file.ID))
+	})
+	if err != nil {
+		return models.File{}, false, err
+	}
+
+	return prev, replaced, nil
+}
+
+func boltBucket(tx *bolt.Tx, name string) (models.Bucket, error) {
+	raw := tx.Bucket(boltBucketsBucket).Get([]byte(name))
+	if raw == nil {
+		return models.Bucket{}, models.ErrBucketNotFound
+	}
+
+	var bucket models.Bucket
+	if err := json.Unmarshal(raw, &bucket); err != nil {
+		return models.Bucket{}, fmt.Errorf("unmarshal bucket: %w", err)
+	}
+
+	return bucket, nil
+}
+
+// CreateAPIKey сохраняет новый API-ключ.
+func (s *BoltStore) CreateAPIKey(_ context.Context, key models.APIKey) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltAPIKeysBucket)
+		if b.Get([]byte(key.ID)) != nil {
+			return fmt.Errorf("api key %s already exists", key.ID)
+		}
+		raw, err := json.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("marshal api key: %w", err)
+		}
+		return b.Put([]byte(key.ID), raw)
+	})
+}
+
+// GetAPIKey возвращает ключ по идентификатору.
+func (s *BoltStore) GetAPIKey(_ context.Context, id string) (models.APIKey, error) {
+	var key models.APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltAPIKeysBucket).Get([]byte(id))
+		if raw == nil {
+			return models.ErrKeyNotFound
+		}
+		if err := json.Unmarshal(raw, &key); err != nil {
+			return fmt.Errorf("unmarshal api key: %w", err)
+		}
+		return nil
+	})
+
+	return key, err
+}
+
+// ListAPIKeys возвращает все ключи в порядке создания.
+func (s *BoltStore) ListAPIKeys(_ context.Context) ([]models.APIKey, error) {
+	var out []models.APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltAPIKeysBucket).ForEach(func(_, raw []byte) error {
+			var key models.APIKey
+			if err := json.Unmarshal(raw, &key); err != nil {
+				return fmt.Errorf("unmarshal api key: %w", err)
+			}
+			out = append(out, key)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortAPIKeys(out)
+	return out, nil
+}
+
+// DeleteAPIKey отзывает ключ.
+func (s *BoltStore) DeleteAPIKey(_ context.Context, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltAPIKeysBucket)
+		if b.Get([]byte(id)) == nil {
+			return models.ErrKeyNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// ListStorageNodes возвращает состав кластера в порядке добавления узлов.
+func (s *BoltStore) ListStorageNodes(_ context.Context) ([]models.StorageNode, error) {
+	var out []models.StorageNode
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltNodesBucket).ForEach(func(_, raw []byte) error {
+			var node models.StorageNode
+			if err := json.Unmarshal(raw, &node); err != nil {
+				return fmt.Errorf("unmarshal storage node: %w", err)
+			}
+			out = append(out, node)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortStorageNodes(out)
+	return out, nil
+}
+
+// PutStorageNode добавляет узел или меняет состояние существующего, сохраняя время добавления.
+func (s *BoltStore) PutStorageNode(_ context.Context, node models.StorageNode) (models.StorageNode, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltNodesBucket)
+		node.CreatedAt = node.UpdatedAt
+		if raw := b.Get([]byte(node.URL)); raw != nil {
+			var prev models.StorageNode
+			if err := json.Unmarshal(raw, &prev); err != nil {
+				return fmt.Errorf("unmarshal storage node: %w", err)
+			}
+			node.CreatedAt = prev.CreatedAt
+		}
+		raw, err := json.Marshal(node)
+		if err != nil {
+			return fmt.Errorf("marshal storage node: %w", err)
+		}
+		return b.Put([]byte(node.URL), raw)
+	})
+	if err != nil {
+		return models.StorageNode{}, err
+	}
+
+	return node, nil
+}
+
+// DeleteStorageNode убирает узел из состава кластера.
+func (s *BoltStore) DeleteStorageNode(_ context.Context, url string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltNodesBucket)
+		if b.Get([]byte(url)) == nil {
+			return models.ErrStorageNotFound
+		}
+		return b.Delete([]byte(url))
+	})
+}
+
+// Close закрывает файл базы.
+func (s *BoltStore) Close() {
+	if s.db != nil {
+		_ = s.db.Close()
+	}
+}
+
+// boltPath извлекает путь к файлу базы из DSN, отбрасывая query-параметры.
+func boltPath(dsn string) (string, error) {
+	_, rest, _ := strings.Cut(strings.TrimSpace(dsn), "://")
+	rest, _, _ = strings.Cut(rest, "?")
+	if rest == "" {
+		return "", fmt.Errorf("bolt dsn must contain a file path: bolt:///path/to/meta.db")
+	}
+
+	return filepath.Clean(rest), nil
+}
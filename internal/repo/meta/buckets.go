@@ -0,0 +1,183 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const (
+	bucketsTable = "buckets"
+
+	// pgUniqueViolation — код ошибки Postgres при нарушении уникального индекса.
+	pgUniqueViolation = "23505"
+	// putObjectAttempts ограничивает повторы PutObject при гонке за новый ключ.
+	putObjectAttempts = 3
+)
+
+// CreateBucket создаёт бакет; models.ErrBucketExists, если имя уже занято.
+func (s *PGStore) CreateBucket(ctx context.Context, name string) (models.Bucket, error) {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Insert(bucketsTable).
+		Columns("name").
+		Values(name).
+		Suffix("ON CONFLICT (name) DO NOTHING RETURNING created_at").
+		ToSql()
+	if err != nil {
+		return models.Bucket{}, fmt.Errorf("build bucket insert: %w", err)
+	}
+
+	bucket := models.Bucket{Name: name}
+	if err = s.pool.QueryRow(ctx, sqlStr, args...).Scan(&bucket.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Bucket{}, models.ErrBucketExists
+		}
+		return models.Bucket{}, fmt.Errorf("exec bucket insert: %w", err)
+	}
+
+	return bucket, nil
+}
+
+// GetBucket возвращает бакет по имени.
+func (s *PGStore) GetBucket(ctx context.Context, name string) (models.Bucket, error) {
+	bucket := models.Bucket{Name: name}
+	err := s.pool.QueryRow(ctx, `SELECT created_at FROM `+bucketsTable+` WHERE name = $1`, name).Scan(&bucket.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Bucket{}, models.ErrBucketNotFound
+		}
+		return models.Bucket{}, fmt.Errorf("select bucket: %w", err)
+	}
+
+	return bucket, nil
+}
+
+// ListBuckets возвращает все бакеты в порядке имён.
+func (s *PGStore) ListBuckets(ctx context.Context) ([]models.Bucket, error) {
+	rows, err := s.pool.Query(ctx, `SELECT name, created_at FROM `+bucketsTable+` ORDER BY name COLLATE "C"`)
+	if err != nil {
+		return nil, fmt.Errorf("query buckets: %w", err)
+	}
+	defer rows.Close()
+
+	var out []models.Bucket
+	for rows.Next() {
+		var b models.Bucket
+		if err = rows.Scan(&b.Name, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan bucket: %w", err)
+		}
+		out = append(out, b)
+	}
+
+	return out, rows.Err()
+}
+
+// DeleteBucket удаляет пустой бакет. Строка бакета блокируется, чтобы в него не записали объект,
+// пока проверяется пустота.
+func (s *PGStore) DeleteBucket(ctx context.Context, name string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err = lockBucket(ctx, tx, name, "FOR UPDATE"); err != nil {
+		return err
+	}
+
+	var nonEmpty bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+filesMetaTable+` WHERE bucket = $1)`, name).Scan(&nonEmpty)
+	if err != nil {
+		return fmt.Errorf("check bucket objects: %w", err)
+	}
+	if nonEmpty {
+		return models.ErrBucketNotEmpty
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM `+bucketsTable+` WHERE name = $1`, name); err != nil {
+		return fmt.Errorf("exec bucket delete: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetObject возвращает объект бакета по ключу.
+func (s *PGStore) GetObject(ctx context.Context, bucket, key string) (models.File, error) {
+	file, err := getFile(ctx, s.pool, sq.Eq{"bucket": bucket, "key": key}, "")
+	if errors.Is(err, models.ErrNotFound) {
+		if _, berr := s.GetBucket(ctx, bucket); berr != nil {
+			return models.File{}, berr
+		}
+	}
+
+	return file, err
+}
+
+// PutObject записывает объект file.Bucket/file.Key, заменяя прежний объект с тем же ключом,
+// и возвращает заменённый. Бакет удерживается FOR SHARE, прежняя строка — FOR UPDATE.
+// Две параллельные записи нового ключа упираются в уникальный индекс: проигравшая повторяется.
+func (s *PGStore) PutObject(ctx context.Context, file models.File) (models.File, bool, error) {
+	for attempt := 1; ; attempt++ {
+		prev, replaced, err := s.putObject(ctx, file)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && attempt < putObjectAttempts {
+			continue
+		}
+		return prev, replaced, err
+	}
+}
+
+func (s *PGStore) putObject(ctx context.Context, file models.File) (models.File, bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return models.File{}, false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err = lockBucket(ctx, tx, file.Bucket, "FOR SHARE"); err != nil {
+		return models.File{}, false, err
+	}
+
+	prev, err := getFile(ctx, tx, sq.Eq{"bucket": file.Bucket, "key": file.Key}, "FOR UPDATE")
+	replaced := err == nil
+	switch {
+	case replaced:
+		if _, err = tx.Exec(ctx, `DELETE FROM `+filesMetaTable+` WHERE id = $1`, prev.ID); err != nil {
+			return models.File{}, false, fmt.Errorf("delete replaced object: %w", err)
+		}
+	case !errors.Is(err, models.ErrNotFound):
+		return models.File{}, false, err
+	}
+
+	sqlStr, args, err := upsertFileSQL(file)
+	if err != nil {
+		return models.File{}, false, err
+	}
+	if _, err = tx.Exec(ctx, sqlStr, args...); err != nil {
+		return models.File{}, false, fmt.Errorf("exec object insert: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.File{}, false, err
+	}
+
+	return prev, replaced, nil
+}
+
+func lockBucket(ctx context.Context, tx pgx.Tx, name, mode string) error {
+	var one int
+	err := tx.QueryRow(ctx, `SELECT 1 FROM `+bucketsTable+` WHERE name = $1 `+mode, name).Scan(&one)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ErrBucketNotFound
+		}
+		return fmt.Errorf("lock bucket: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package meta
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// listCursor — позиция последнего элемента страницы в порядке сортировки (keyset pagination).
+type listCursor struct {
+	Sort  models.ListSort `json:"s"`
+	Desc  bool            `json:"d,omitempty"`
+	Value int64           `json:"v"`
+	Name  string          `json:"n,omitempty"`
+	ID    string          `json:"id"`
+}
+
+func encodeCursor(f models.ListFilter, last models.File) string {
+	b, _ := json.Marshal(listCursor{
+		Sort:  f.SortBy,
+		Desc:  f.Desc,
+		Value: sortValue(f.SortBy, last),
+		Name:  sortName(f.SortBy, last),
+		ID:    last.ID,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor разбирает курсор и проверяет, что он выдан для того же порядка сортировки.
+func decodeCursor(f models.ListFilter) (*listCursor, error) {
+	if f.Cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return nil, models.ErrBadCursor
+	}
+
+	var c listCursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, models.ErrBadCursor
+	}
+	if c.Sort != f.SortBy || c.Desc != f.Desc {
+		return nil, fmt.Errorf("%w: cursor was issued for another sort order", models.ErrBadCursor)
+	}
+
+	return &c, nil
+}
+
+func sortValue(by models.ListSort, f models.File) int64 {
+	switch by {
+	case models.SortBySize:
+		return f.Size
+	case models.SortByName:
+		return 0
+	default:
+		return f.CreatedAt.UnixMicro()
+	}
+}
+
+func sortName(by models.ListSort, f models.File) string {
+	if by == models.SortByName {
+		return f.Name
+	}
+	return ""
+}
+
+// sortColumn возвращает колонку files_meta, соответствующую полю сортировки.
+// Имена сравниваются в collation "C", то есть побайтово, как строки в Go;
+// внутри бакета сортировка идёт по ключу (он совпадает с именем объекта).
+func sortColumn(f models.ListFilter) (string, error) {
+	if f.After != "" && f.SortBy != models.SortByName {
+		return "", fmt.Errorf("after requires sort by %q", models.SortByName)
+	}
+
+	switch f.SortBy {
+	case models.SortByCreated:
+		return "created_at", nil
+	case models.SortBySize:
+		return "size", nil
+	case models.SortByName:
+		if f.Bucket != "" {
+			return `key COLLATE "C"`, nil
+		}
+		return `file_name COLLATE "C"`, nil
+	default:
+		return "", fmt.Errorf("unsupported sort field %q", f.SortBy)
+	}
+}
+
+// sortKey — позиция файла в порядке сортировки.
+type sortKey struct {
+	value int64
+	name  string
+	id    string
+}
+
+func keyOf(by models.ListSort, f models.File) sortKey {
+	return sortKey{value: sortValue(by, f), name: sortName(by, f), id: f.ID}
+}
+
+// listInMemory реализует List поверх полного набора файлов: для memory- и bolt-бэкендов.
+func listInMemory(all []models.File, f models.ListFilter) (models.FileList, error) {
+	f = f.Normalize()
+	if _, err := sortColumn(f); err != nil {
+		return models.FileList{}, err
+	}
+	cur, err := decodeCursor(f)
+	if err != nil {
+		return models.FileList{}, err
+	}
+
+	// less задаёт порядок (значение сортировки, id) с учётом направления.
+	less := func(a, b sortKey) bool {
+		if a.value != b.value {
+			return (a.value < b.value) != f.Desc
+		}
+		if a.name != b.name {
+			return (a.name < b.name) != f.Desc
+		}
+		if a.id == b.id {
+			return false
+		}
+		return (a.id < b.id) != f.Desc
+	}
+
+	matched := make([]models.File, 0, len(all))
+	for _, file := range all {
+		if f.Bucket != "" && file.Bucket != f.Bucket {
+			continue
+		}
+		if !strings.HasPrefix(file.Name, f.Prefix) {
+			continue
+		}
+		if f.After != "" && file.Name <= f.After {
+			continue
+		}
+		if cur != nil && !less(sortKey{value: cur.Value, name: cur.Name, id: cur.ID}, keyOf(f.SortBy, file)) {
+			continue
+		}
+		matched = append(matched, file)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return less(keyOf(f.SortBy, matched[i]), keyOf(f.SortBy, matched[j]))
+	})
+
+	return pageOf(matched, f), nil
+}
+
+// pageOf обрезает отсортированную выборку (до limit+1 элементов) до страницы и вычисляет следующий курсор.
+func pageOf(files []models.File, f models.ListFilter) models.FileList {
+	if len(files) <= f.Limit {
+		return models.FileList{Files: files}
+	}
+
+	page := files[:f.Limit]
+	return models.FileList{
+		Files:      page,
+		NextCursor: encodeCursor(f, page[len(page)-1]),
+	}
+}
@@ -0,0 +1,35 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// Delete удаляет описание файла. Возвращает models.ErrNotFound, если файла нет.
+func (s *PGStore) Delete(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("file id is empty")
+	}
+
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Delete(filesMetaTable).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete: %w", err)
+	}
+
+	tag, err := s.pool.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		return fmt.Errorf("exec delete: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
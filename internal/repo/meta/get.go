@@ -6,61 +6,80 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/sir_venger/s3_lite/internal/models"
 )
 
+// rowQuerier — общее у пула и транзакции: выборка одной строки.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // Get возвращает описание файла по его идентификатору.
 func (s *PGStore) Get(ctx context.Context, id string) (models.File, error) {
 	if strings.TrimSpace(id) == "" {
 		return models.File{}, fmt.Errorf("file id is empty")
 	}
 
+	return getFile(ctx, s.pool, sq.Eq{"id": id}, "")
+}
+
+// getFile читает одну строку files_meta по условию where; suffix — например, FOR UPDATE.
+func getFile(ctx context.Context, q rowQuerier, where sq.Sqlizer, suffix string) (models.File, error) {
 	// COALESCE(parts, '{}') — чтобы гарантированно получить валидный JSON для Unmarshal, это для себя комментарий
 	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
 		Select(
+			"id",
 			"file_name",
 			"total_parts",
 			"size",
 			"COALESCE(parts, '{}'::jsonb) AS parts",
+			"created_at",
+			"erasure",
+			"COALESCE(bucket, '')",
+			"COALESCE(key, '')",
+			"tus",
 		).
 		From(filesMetaTable).
-		Where(sq.Eq{"id": id}).
+		Where(where).
 		Limit(1).
+		Suffix(suffix).
 		ToSql()
 	if err != nil {
 		return models.File{}, fmt.Errorf("build select: %w", err)
 	}
 
 	var (
-		name       string
-		totalParts int
-		size       int64
+		file       models.File
 		partsRaw   []byte
+		createdAt  time.Time
+		erasureRaw []byte
 	)
 
-	if err = s.pool.QueryRow(ctx, sqlStr, args...).Scan(&name, &totalParts, &size, &partsRaw); err != nil {
+	err = q.QueryRow(ctx, sqlStr, args...).Scan(
+		&file.ID, &file.Name, &file.TotalParts, &file.Size, &partsRaw, &createdAt, &erasureRaw, &file.Bucket, &file.Key, &file.Tus)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.File{}, models.ErrNotFound
 		}
 		return models.File{}, fmt.Errorf("scan file row: %w", err)
 	}
+	file.CreatedAt = createdAt
 
-	var parts map[int]models.Part
-	if err := json.Unmarshal(partsRaw, &parts); err != nil {
+	if err := json.Unmarshal(partsRaw, &file.Parts); err != nil {
 		return models.File{}, fmt.Errorf("unmarshal parts: %w", err)
 	}
-	if parts == nil {
-		parts = make(map[int]models.Part)
+	if file.Parts == nil {
+		file.Parts = make(map[int]models.Part)
+	}
+	if len(erasureRaw) > 0 {
+		if err := json.Unmarshal(erasureRaw, &file.Erasure); err != nil {
+			return models.File{}, fmt.Errorf("unmarshal erasure: %w", err)
+		}
 	}
 
-	return models.File{
-		ID:         id,
-		Name:       name,
-		Size:       size,
-		TotalParts: totalParts,
-		Parts:      parts,
-	}.Clone(), nil
+	return file.Clone(), nil
 }
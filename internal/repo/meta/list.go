@@ -0,0 +1,92 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// List возвращает страницу файлов с фильтром по префиксу имени (ключа — внутри бакета).
+// Пагинация по ключу (значение сортировки, id) опирается на индексы files_meta_*_idx.
+func (s *PGStore) List(ctx context.Context, f models.ListFilter) (models.FileList, error) {
+	f = f.Normalize()
+	column, err := sortColumn(f)
+	if err != nil {
+		return models.FileList{}, err
+	}
+	cur, err := decodeCursor(f)
+	if err != nil {
+		return models.FileList{}, err
+	}
+
+	direction := "ASC"
+	if f.Desc {
+		direction = "DESC"
+	}
+
+	q := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("id", "file_name", "total_parts", "size", "created_at", "COALESCE(bucket, '')", "COALESCE(key, '')").
+		From(filesMetaTable).
+		OrderBy(column+" "+direction, "id "+direction).
+		Limit(uint64(f.Limit + 1))
+
+	nameColumn := "file_name"
+	if f.Bucket != "" {
+		q = q.Where(sq.Eq{"bucket": f.Bucket})
+		nameColumn = `key COLLATE "C"`
+	}
+	if f.Prefix != "" {
+		q = q.Where(sq.Expr(nameColumn+` LIKE ? ESCAPE '\'`, escapeLike(f.Prefix)+"%"))
+	}
+	if f.After != "" {
+		q = q.Where(sq.Expr(column+" > ?", f.After))
+	}
+	if cur != nil {
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		var value any = cur.Value
+		switch f.SortBy {
+		case models.SortByCreated:
+			value = time.UnixMicro(cur.Value).UTC()
+		case models.SortByName:
+			value = cur.Name
+		}
+		q = q.Where(sq.Expr(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), value, cur.ID))
+	}
+
+	sqlStr, args, err := q.ToSql()
+	if err != nil {
+		return models.FileList{}, fmt.Errorf("build list: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, sqlStr, args...)
+	if err != nil {
+		return models.FileList{}, fmt.Errorf("query list: %w", err)
+	}
+	defer rows.Close()
+
+	files := make([]models.File, 0, f.Limit+1)
+	for rows.Next() {
+		var file models.File
+		if err = rows.Scan(&file.ID, &file.Name, &file.TotalParts, &file.Size, &file.CreatedAt, &file.Bucket, &file.Key); err != nil {
+			return models.FileList{}, fmt.Errorf("scan list row: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err = rows.Err(); err != nil {
+		return models.FileList{}, err
+	}
+
+	return pageOf(files, f), nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы префикс искался буквально.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
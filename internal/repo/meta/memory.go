@@ -0,0 +1,562 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const memoryScheme = "memory"
+
+var (
+	memoryStoresMu sync.Mutex
+	memoryStores   = map[string]*MemoryStore{}
+)
+
+// MemoryStore хранит метаданные в памяти процесса. Подходит для тестов и локальной отладки.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	files   map[string]models.File
+	pending map[string]models.PendingDeletion
+	uploads map[string]models.Upload
+	// uploadLocks — аренды дозаписи в сессии (LockUpload).
+	uploadLocks map[string]uploadLease
+	buckets     map[string]models.Bucket
+	// objects — идентификатор файла по objectKey(бакет, ключ).
+	objects map[string]string
+	apiKeys map[string]models.APIKey
+	nodes   map[string]models.StorageNode
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore создаёт пустое изолированное хранилище.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		files:       make(map[string]models.File),
+		pending:     make(map[string]models.PendingDeletion),
+		uploads:     make(map[string]models.Upload),
+		uploadLocks: make(map[string]uploadLease),
+		buckets:     make(map[string]models.Bucket),
+		objects:     make(map[string]string),
+		apiKeys:     make(map[string]models.APIKey),
+		nodes:       make(map[string]models.StorageNode),
+	}
+}
+
+// openMemoryStore возвращает именованное хранилище для DSN memory://<name>.
+// Одинаковые имена внутри процесса разделяют одни и те же данные.
+func openMemoryStore(dsn string) (*MemoryStore, error) {
+	_, name, _ := strings.Cut(dsn, "://")
+	name = strings.Trim(strings.TrimSpace(name), "/")
+	if name == "" {
+		return nil, fmt.Errorf("memory dsn must name the store: memory://<name>")
+	}
+
+	memoryStoresMu.Lock()
+	defer memoryStoresMu.Unlock()
+
+	if st, ok := memoryStores[name]; ok {
+		return st, nil
+	}
+	st := NewMemoryStore()
+	memoryStores[name] = st
+
+	return st, nil
+}
+
+// Get возвращает копию описания файла.
+func (s *MemoryStore) Get(_ context.Context, id string) (models.File, error) {
+	if strings.TrimSpace(id) == "" {
+		return models.File{}, fmt.Errorf("file id is empty")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	file, ok := s.files[id]
+	if !ok {
+		return models.File{}, models.ErrNotFound
+	}
+
+	return file.Clone(), nil
+}
+
+// Save записывает (или заменяет) описание файла.
+func (s *MemoryStore) Save(_ context.Context, file models.File) error {
+	if strings.TrimSpace(file.ID) == "" {
+		return fmt.Errorf("file id is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if prev, ok := s.files[file.ID]; ok {
+		file.CreatedAt = prev.CreatedAt
+	} else if file.CreatedAt.IsZero() {
+		file.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	}
+	s.files[file.ID] = file.Clone()
+
+	return nil
+}
+
+// List возвращает страницу файлов с фильтром по префиксу имени.
+func (s *MemoryStore) List(_ context.Context, f models.ListFilter) (models.FileList, error) {
+	s.mu.RLock()
+	all := make([]models.File, 0, len(s.files))
+	for _, file := range s.files {
+		all = append(all, file.Clone())
+	}
+	s.mu.RUnlock()
+
+	return listInMemory(all, f)
+}
+
+// Delete удаляет описание файла.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("file id is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.files[id]
+	if !ok {
+		return models.ErrNotFound
+	}
+	delete(s.files, id)
+	if file.Bucket != "" {
+		delete(s.objects, objectKey(file.Bucket, file.Key))
+	}
+
+	return nil
+}
+
+// AddPendingDeletions ставит части в очередь на повторное удаление.
+func (s *MemoryStore) AddPendingDeletions(_ context.Context, items []models.PendingDeletion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, d := range items {
+		key := d.Key()
+		if prev, ok := s.pending[key]; ok {
+			d.CreatedAt = prev.CreatedAt
+		} else if d.CreatedAt.IsZero() {
+			d.CreatedAt = now
+		}
+		s.pending[key] = d
+	}
+
+	return nil
+}
+
+// ListPendingDeletions возвращает до limit самых старых записей очереди.
+func (s *MemoryStore) ListPendingDeletions(_ context.Context, limit int) ([]models.PendingDeletion, error) {
+	s.mu.RLock()
+	out := make([]models.PendingDeletion, 0, len(s.pending))
+	for _, d := range s.pending {
+		out = append(out, d)
+	}
+	s.mu.RUnlock()
+
+	return oldestPending(out, limit), nil
+}
+
+// RemovePendingDeletion убирает часть из очереди.
+func (s *MemoryStore) RemovePendingDeletion(_ context.Context, d models.PendingDeletion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, d.Key())
+
+	return nil
+}
+
+// CreateUpload регистрирует новую сессию составной загрузки.
+func (s *MemoryStore) CreateUpload(_ context.Context, upload models.Upload) error {
+	if strings.TrimSpace(upload.ID) == "" {
+		return fmt.Errorf("upload id is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.uploads[upload.ID]; ok {
+		return fmt.Errorf("upload %s already exists", upload.ID)
+	}
+	if upload.Parts == nil {
+		upload.Parts = make(map[int]models.Part)
+	}
+	s.uploads[upload.ID] = upload.Clone()
+
+	return nil
+}
+
+// GetUpload возвращает копию сессии вместе с принятыми частями.
+func (s *MemoryStore) GetUpload(_ context.Context, id string) (models.Upload, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	upload, ok := s.uploads[id]
+	if !ok {
+		return models.Upload{}, models.ErrUploadNotFound
+	}
+
+	return upload.Clone(), nil
+}
+
+// ListExpiredUploads возвращает до limit сессий, срок которых истёк к моменту now.
+func (s *MemoryStore) ListExpiredUploads(_ context.Context, now time.Time, limit int) ([]models.Upload, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []models.Upload
+	for _, upload := range s.uploads {
+		if upload.Expired(now) {
+			out = append(out, upload.Clone())
+		}
+	}
+
+	return oldestExpired(out, limit), nil
+}
+
+// PutUploadPart записывает часть сессии и возвращает ту, которую она заменила.
+func (s *MemoryStore) PutUploadPart(_ context.Context, id string, part models.Part) (models.Part, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	upload, ok := s.uploads[id]
+	if !ok {
+		return models.Part{}, false, models.ErrUploadNotFound
+	}
+	prev, replaced := upload.Parts[part.Index]
+	upload.Parts[part.Index] = part.Clone()
+
+	return prev, replaced, nil
+}
+
+// DeleteUpload удаляет сессию.
+func (s *MemoryStore) DeleteUpload(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.uploads[id]; !ok {
+		return models.ErrUploadNotFound
+	}
+	delete(s.uploads, id)
+	delete(s.uploadLocks, id)
+
+	return nil
+}
+
+// LockUpload берёт или продлевает на ttl аренду дозаписи в сессию для owner.
+// models.ErrUploadLocked, если действующая аренда принадлежит другому владельцу.
+func (s *MemoryStore) LockUpload(_ context.Context, id, owner string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.uploads[id]; !ok {
+		return models.ErrUploadNotFound
+	}
+	now := time.Now()
+	if !s.uploadLocks[id].free(owner, now) {
+		return models.ErrUploadLocked
+	}
+	s.uploadLocks[id] = uploadLease{Owner: owner, Until: now.Add(ttl)}
+
+	return nil
+}
+
+// UnlockUpload снимает аренду дозаписи, если она принадлежит owner.
+func (s *MemoryStore) UnlockUpload(_ context.Context, id, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.uploadLocks[id].Owner == owner {
+		delete(s.uploadLocks, id)
+	}
+
+	return nil
+}
+
+// CreateBucket создаёт бакет; models.ErrBucketExists, если имя уже занято.
+func (s *MemoryStore) CreateBucket(_ context.Context, name string) (models.Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[name]; ok {
+		return models.Bucket{}, models.ErrBucketExists
+	}
+	bucket := models.Bucket{Name: name, CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	s.buckets[name] = bucket
+
+	return bucket, nil
+}
+
+// GetBucket возвращает бакет по имени.
+func (s *MemoryStore) GetBucket(_ context.Context, name string) (models.Bucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bucket, ok := s.buckets[name]
+	if !ok {
+		return models.Bucket{}, models.ErrBucketNotFound
+	}
+
+	return bucket, nil
+}
+
+// ListBuckets возвращает все бакеты в порядке имён.
+func (s *MemoryStore) ListBuckets(_ context.Context) ([]models.Bucket, error) {
+	s.mu.RLock()
+	out := make([]models.Bucket, 0, len(s.buckets))
+	for _, b := range s.buckets {
+		out = append(out, b)
+	}
+	s.mu.RUnlock()
+
+	sortBuckets(out)
+	return out, nil
+}
+
+// DeleteBucket удаляет пустой бакет.
+func (s *MemoryStore) DeleteBucket(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[name]; !ok {
+		return models.ErrBucketNotFound
+	}
+	for _, file := range s.files {
+		if file.Bucket == name {
+			return models.ErrBucketNotEmpty
+		}
+	}
+	delete(s.buckets, name)
+
+	return nil
+}
+
+// GetObject возвращает копию объекта бакета по ключу.
+func (s *MemoryStore) GetObject(_ context.Context, bucket, key string) (models.File, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.buckets[bucket]; !ok {
+		return models.File{}, models.ErrBucketNotFound
+	}
+	id, ok := s.objects[objectKey(bucket, key)]
+	if !ok {
+		return models.File{}, models.ErrNotFound
+	}
+
+	return s.files[id].Clone(), nil
+}
+
+// PutObject записывает объект, заменяя прежний с тем же ключом, и возвращает заменённый.
+func (s *MemoryStore) PutObject(_ context.Context, file models.File) (models.File, bool, error) {
+	if strings.TrimSpace(file.ID) == "" {
+		return models.File{}, false, fmt.Errorf("file id is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[file.Bucket]; !ok {
+		return models.File{}, false, models.ErrBucketNotFound
+	}
+
+	k := objectKey(file.Bucket, file.Key)
+	var prev models.File
+	id, replaced := s.objects[k]
+	if replaced {
+		prev = s.files[id]
+		delete(s.files, id)
+	}
+	if file.CreatedAt.IsZero() {
+		file.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	}
+	s.files[file.ID] = file.Clone()
+	s.objects[k] = file.ID
+
+	return prev, replaced, nil
+}
+
+// CreateAPIKey сохраняет новый API-ключ.
+func (s *MemoryStore) CreateAPIKey(_ context.Context, key models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.apiKeys[key.ID]; ok {
+		return fmt.Errorf("api key %s already exists", key.ID)
+	}
+	key.Permissions = slices.Clone(key.Permissions)
+	s.apiKeys[key.ID] = key
+
+	return nil
+}
+
+// GetAPIKey возвращает ключ по идентификатору.
+func (s *MemoryStore) GetAPIKey(_ context.Context, id string) (models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return models.APIKey{}, models.ErrKeyNotFound
+	}
+	key.Permissions = slices.Clone(key.Permissions)
+
+	return key, nil
+}
+
+// ListAPIKeys возвращает все ключи в порядке создания.
+func (s *MemoryStore) ListAPIKeys(_ context.Context) ([]models.APIKey, error) {
+	s.mu.RLock()
+	out := make([]models.APIKey, 0, len(s.apiKeys))
+	for _, key := range s.apiKeys {
+		key.Permissions = slices.Clone(key.Permissions)
+		out = append(out, key)
+	}
+	s.mu.RUnlock()
+
+	sortAPIKeys(out)
+	return out, nil
+}
+
+// DeleteAPIKey отзывает ключ.
+func (s *MemoryStore) DeleteAPIKey(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.apiKeys[id]; !ok {
+		return models.ErrKeyNotFound
+	}
+	delete(s.apiKeys, id)
+
+	return nil
+}
+
+// ListStorageNodes возвращает состав кластера в порядке добавления узлов.
+func (s *MemoryStore) ListStorageNodes(_ context.Context) ([]models.StorageNode, error) {
+	s.mu.RLock()
+	out := make([]models.StorageNode, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		out = append(out, node)
+	}
+	s.mu.RUnlock()
+
+	sortStorageNodes(out)
+	return out, nil
+}
+
+// PutStorageNode добавляет узел или меняет состояние существующего, сохраняя время добавления.
+func (s *MemoryStore) PutStorageNode(_ context.Context, node models.StorageNode) (models.StorageNode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node.CreatedAt = node.UpdatedAt
+	if prev, ok := s.nodes[node.URL]; ok {
+		node.CreatedAt = prev.CreatedAt
+	}
+	s.nodes[node.URL] = node
+
+	return node, nil
+}
+
+// DeleteStorageNode убирает узел из состава кластера.
+func (s *MemoryStore) DeleteStorageNode(_ context.Context, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodes[url]; !ok {
+		return models.ErrStorageNotFound
+	}
+	delete(s.nodes, url)
+
+	return nil
+}
+
+// Close ничего не делает: данные живут до завершения процесса.
+func (s *MemoryStore) Close() {}
+
+// objectKey — ключ индекса объектов: имя бакета не содержит нулевого байта.
+func objectKey(bucket, key string) string {
+	return bucket + "\x00" + key
+}
+
+func sortBuckets(buckets []models.Bucket) {
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Name < buckets[j].Name
+	})
+}
+
+func sortAPIKeys(keys []models.APIKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+}
+
+func sortStorageNodes(nodes []models.StorageNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if !nodes[i].CreatedAt.Equal(nodes[j].CreatedAt) {
+			return nodes[i].CreatedAt.Before(nodes[j].CreatedAt)
+		}
+		return nodes[i].URL < nodes[j].URL
+	})
+}
+
+// uploadLease — аренда дозаписи в сессию.
+type uploadLease struct {
+	Owner string    `json:"owner"`
+	Until time.Time `json:"until"`
+}
+
+// free сообщает, может ли owner взять аренду к моменту now.
+func (l uploadLease) free(owner string, now time.Time) bool {
+	return l.Owner == "" || l.Owner == owner || !now.Before(l.Until)
+}
+
+// oldestExpired сортирует сессии по сроку и обрезает список до limit.
+func oldestExpired(uploads []models.Upload, limit int) []models.Upload {
+	sort.Slice(uploads, func(i, j int) bool {
+		if !uploads[i].ExpiresAt.Equal(uploads[j].ExpiresAt) {
+			return uploads[i].ExpiresAt.Before(uploads[j].ExpiresAt)
+		}
+		return uploads[i].ID < uploads[j].ID
+	})
+	if limit > 0 && len(uploads) > limit {
+		uploads = uploads[:limit]
+	}
+
+	return uploads
+}
+
+// oldestPending сортирует очередь по времени постановки и обрезает её до limit.
+func oldestPending(items []models.PendingDeletion, limit int) []models.PendingDeletion {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].Key() < items[j].Key()
+	})
+	if limit <= 0 {
+		return nil
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items
+}
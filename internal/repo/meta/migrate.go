@@ -0,0 +1,160 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sir_venger/s3_lite/internal/repo/migrations"
+)
+
+const (
+	schemaVersionTable = "schema_version"
+	// migrationLockKey — ключ advisory-lock, чтобы несколько REST-реплик не накатывали схему одновременно.
+	migrationLockKey int64 = 0x73336c697465
+)
+
+// Migrator реализуют бэкенды, схема которых управляется версионными миграциями.
+type Migrator interface {
+	// Migrate накатывает все ещё не применённые миграции.
+	Migrate(ctx context.Context) error
+	// MigrateTo приводит схему к указанной версии, откатывая лишние миграции при необходимости.
+	MigrateTo(ctx context.Context, version int64) error
+	// SchemaVersion возвращает текущую версию схемы (0 — схема пуста).
+	SchemaVersion(ctx context.Context) (int64, error)
+}
+
+var _ Migrator = (*PGStore)(nil)
+
+// Migrate накатывает все встроенные миграции.
+func (s *PGStore) Migrate(ctx context.Context) error {
+	all, err := migrations.Load()
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	return s.MigrateTo(ctx, all[len(all)-1].Version)
+}
+
+// MigrateTo накатывает или откатывает миграции до версии target.
+func (s *PGStore) MigrateTo(ctx context.Context, target int64) error {
+	if target < 0 {
+		return fmt.Errorf("target schema version must be non-negative")
+	}
+
+	all, err := migrations.Load()
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+	}()
+
+	if _, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+schemaVersionTable+` (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("create %s: %w", schemaVersionTable, err)
+	}
+
+	current, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+
+	if target >= current {
+		for _, m := range all {
+			if m.Version <= current || m.Version > target {
+				continue
+			}
+			if err = applyMigration(ctx, conn.Conn(), m, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := len(all) - 1; i >= 0; i-- {
+		m := all[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		if err = applyMigration(ctx, conn.Conn(), m, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SchemaVersion возвращает номер последней применённой миграции.
+func (s *PGStore) SchemaVersion(ctx context.Context) (int64, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	return currentVersion(ctx, conn.Conn())
+}
+
+func currentVersion(ctx context.Context, conn *pgx.Conn) (int64, error) {
+	var v int64
+	err := conn.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+schemaVersionTable).Scan(&v)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+
+	return v, nil
+}
+
+// applyMigration выполняет секцию Up или Down в одной транзакции вместе с записью в schema_version.
+func applyMigration(ctx context.Context, conn *pgx.Conn, m migrations.Migration, up bool) (err error) {
+	script := m.Up
+	if !up {
+		script = m.Down
+		if script == "" {
+			return fmt.Errorf("migration %s has no down section", m.Name)
+		}
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback(context.Background()))
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, script); err != nil {
+		return fmt.Errorf("apply migration %s: %w", m.Name, err)
+	}
+
+	if up {
+		_, err = tx.Exec(ctx, `INSERT INTO `+schemaVersionTable+` (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM `+schemaVersionTable+` WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("record migration %s: %w", m.Name, err)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,92 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const pendingDeletionsTable = "pending_deletions"
+
+// AddPendingDeletions ставит части в очередь на повторное удаление.
+// Для уже известных частей увеличивает счётчик попыток и обновляет текст ошибки.
+func (s *PGStore) AddPendingDeletions(ctx context.Context, items []models.PendingDeletion) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	b := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Insert(pendingDeletionsTable).
+		Columns("file_id", "storage", "part_index", "attempts", "last_error")
+	for _, d := range items {
+		b = b.Values(d.FileID, d.Storage, d.Index, d.Attempts, d.LastError)
+	}
+
+	sqlStr, args, err := b.Suffix(`
+					ON CONFLICT (file_id, storage, part_index) DO UPDATE
+					SET attempts   = EXCLUDED.attempts,
+						last_error = EXCLUDED.last_error`).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build pending upsert: %w", err)
+	}
+
+	if _, err = s.pool.Exec(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("exec pending upsert: %w", err)
+	}
+
+	return nil
+}
+
+// ListPendingDeletions возвращает до limit самых старых записей очереди.
+func (s *PGStore) ListPendingDeletions(ctx context.Context, limit int) ([]models.PendingDeletion, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("file_id", "storage", "part_index", "attempts", "last_error", "created_at").
+		From(pendingDeletionsTable).
+		OrderBy("created_at", "file_id", "storage", "part_index").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build pending select: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query pending: %w", err)
+	}
+	defer rows.Close()
+
+	var out []models.PendingDeletion
+	for rows.Next() {
+		var d models.PendingDeletion
+		if err = rows.Scan(&d.FileID, &d.Storage, &d.Index, &d.Attempts, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan pending row: %w", err)
+		}
+		out = append(out, d)
+	}
+
+	return out, rows.Err()
+}
+
+// RemovePendingDeletion убирает часть из очереди после успешного удаления.
+func (s *PGStore) RemovePendingDeletion(ctx context.Context, d models.PendingDeletion) error {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Delete(pendingDeletionsTable).
+		Where(sq.Eq{"file_id": d.FileID, "storage": d.Storage, "part_index": d.Index}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build pending delete: %w", err)
+	}
+
+	if _, err = s.pool.Exec(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("exec pending delete: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// Store описывает операции, которые обязан поддерживать любой бэкенд метаданных.
+type Store interface {
+	Get(ctx context.Context, id string) (models.File, error)
+	Save(ctx context.Context, file models.File) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filter models.ListFilter) (models.FileList, error)
+
+	AddPendingDeletions(ctx context.Context, items []models.PendingDeletion) error
+	ListPendingDeletions(ctx context.Context, limit int) ([]models.PendingDeletion, error)
+	RemovePendingDeletion(ctx context.Context, d models.PendingDeletion) error
+
+	CreateUpload(ctx context.Context, upload models.Upload) error
+	GetUpload(ctx context.Context, id string) (models.Upload, error)
+	PutUploadPart(ctx context.Context, id string, part models.Part) (prev models.Part, replaced bool, err error)
+	ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]models.Upload, error)
+	DeleteUpload(ctx context.Context, id string) error
+	LockUpload(ctx context.Context, id, owner string, ttl time.Duration) error
+	UnlockUpload(ctx context.Context, id, owner string) error
+
+	CreateBucket(ctx context.Context, name string) (models.Bucket, error)
+	GetBucket(ctx context.Context, name string) (models.Bucket, error)
+	ListBuckets(ctx context.Context) ([]models.Bucket, error)
+	DeleteBucket(ctx context.Context, name string) error
+	GetObject(ctx context.Context, bucket, key string) (models.File, error)
+	PutObject(ctx context.Context, file models.File) (prev models.File, replaced bool, err error)
+
+	CreateAPIKey(ctx context.Context, key models.APIKey) error
+	GetAPIKey(ctx context.Context, id string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	DeleteAPIKey(ctx context.Context, id string) error
+
+	ListStorageNodes(ctx context.Context) ([]models.StorageNode, error)
+	PutStorageNode(ctx context.Context, node models.StorageNode) (models.StorageNode, error)
+	DeleteStorageNode(ctx context.Context, url string) error
+
+	Close()
+}
+
+// Opener создаёт хранилище по DSN зарегистрированной схемы.
+type Opener func(ctx context.Context, dsn string) (Store, error)
+
+var (
+	openersMu sync.RWMutex
+	openers   = map[string]Opener{}
+)
+
+func init() {
+	pg := func(ctx context.Context, dsn string) (Store, error) {
+		return NewPGStore(ctx, dsn)
+	}
+	Register("postgres", pg)
+	Register("postgresql", pg)
+	Register(memoryScheme, func(_ context.Context, dsn string) (Store, error) {
+		return openMemoryStore(dsn)
+	})
+}
+
+// Register связывает схему DSN с конструктором хранилища. Повторная регистрация заменяет прежнюю.
+func Register(scheme string, opener Opener) {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "" || opener == nil {
+		panic("meta: invalid opener registration")
+	}
+
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	openers[scheme] = opener
+}
+
+// Schemes возвращает отсортированный список зарегистрированных схем.
+func Schemes() []string {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+
+	out := make([]string, 0, len(openers))
+	for s := range openers {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Open выбирает бэкенд по схеме DSN, открывает хранилище и накатывает недостающие миграции.
+func Open(ctx context.Context, dsn string) (Store, error) {
+	st, err := openRaw(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if m, ok := st.(Migrator); ok {
+		if err = m.Migrate(ctx); err != nil {
+			st.Close()
+			return nil, fmt.Errorf("migrate meta schema: %w", err)
+		}
+	}
+
+	return st, nil
+}
+
+// MigrateUp накатывает все миграции и возвращает итоговую версию схемы.
+// Для бэкендов без схемы ничего не делает и возвращает 0.
+func MigrateUp(ctx context.Context, dsn string) (int64, error) {
+	return withMigrator(ctx, dsn, func(m Migrator) error {
+		return m.Migrate(ctx)
+	})
+}
+
+// MigrateTo приводит схему к версии version (в том числе откатывая миграции)
+// и возвращает итоговую версию. Для бэкендов без схемы ничего не делает.
+func MigrateTo(ctx context.Context, dsn string, version int64) (int64, error) {
+	return withMigrator(ctx, dsn, func(m Migrator) error {
+		return m.MigrateTo(ctx, version)
+	})
+}
+
+func withMigrator(ctx context.Context, dsn string, fn func(Migrator) error) (int64, error) {
+	st, err := openRaw(ctx, dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer st.Close()
+
+	m, ok := st.(Migrator)
+	if !ok {
+		return 0, nil
+	}
+	if err = fn(m); err != nil {
+		return 0, err
+	}
+
+	return m.SchemaVersion(ctx)
+}
+
+func openRaw(ctx context.Context, dsn string) (Store, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return nil, fmt.Errorf("meta dsn is empty")
+	}
+
+	scheme, err := dsnScheme(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	openersMu.RLock()
+	opener, ok := openers[scheme]
+	openersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported meta dsn scheme %q (known: %s)", scheme, strings.Join(Schemes(), ", "))
+	}
+
+	return opener(ctx, dsn)
+}
+
+// dsnScheme извлекает схему DSN. Строки вида "host=... user=..." считаются DSN Postgres.
+func dsnScheme(dsn string) (string, error) {
+	scheme, _, ok := strings.Cut(dsn, "://")
+	if !ok {
+		if strings.Contains(dsn, "=") {
+			return "postgres", nil
+		}
+		return "", fmt.Errorf("meta dsn has no scheme")
+	}
+
+	return strings.ToLower(scheme), nil
+}
@@ -15,7 +15,8 @@ type PGStore struct {
 
 const filesMetaTable = "files_meta"
 
-// NewPGStore создаёт подключение к Postgres и гарантирует наличие таблицы для файлов.
+// NewPGStore создаёт пул подключений к Postgres. Схему не трогает:
+// таблицы создаются миграциями (см. Migrate, meta.Open накатывает их автоматически).
 func NewPGStore(ctx context.Context, dsn string) (*PGStore, error) {
 	if strings.TrimSpace(dsn) == "" {
 		return nil, fmt.Errorf("meta dsn is empty")
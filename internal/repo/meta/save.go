@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/sir_venger/s3_lite/internal/models"
@@ -11,35 +12,64 @@ import (
 
 // Save записывает (или обновляет) описание файла.
 func (s *PGStore) Save(ctx context.Context, file models.File) error {
+	sqlStr, args, err := upsertFileSQL(file)
+	if err != nil {
+		return err
+	}
+
+	// Выполнение
+	if _, err := s.pool.Exec(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("exec upsert: %w", err)
+	}
+
+	return nil
+}
+
+// upsertFileSQL строит upsert строки files_meta. Принадлежность к бакету при обновлении не меняется.
+func upsertFileSQL(file models.File) (string, []any, error) {
 	if file.Parts == nil {
 		file.Parts = make(map[int]models.Part)
 	}
+	if file.CreatedAt.IsZero() {
+		file.CreatedAt = time.Now().UTC()
+	}
 
 	// Подготовка данных
 	partsJSON, err := json.Marshal(file.Parts)
 	if err != nil {
-		return fmt.Errorf("marshal parts: %w", err)
+		return "", nil, fmt.Errorf("marshal parts: %w", err)
+	}
+	var erasureJSON []byte
+	if file.Erasure != nil {
+		if erasureJSON, err = json.Marshal(file.Erasure); err != nil {
+			return "", nil, fmt.Errorf("marshal erasure: %w", err)
+		}
 	}
 
 	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
 		Insert(filesMetaTable).
-		Columns("id", "file_name", "total_parts", "size", "parts").
-		Values(file.ID, file.Name, file.TotalParts, file.Size, partsJSON).
+		Columns("id", "file_name", "total_parts", "size", "parts", "created_at", "erasure", "bucket", "key", "tus").
+		Values(file.ID, file.Name, file.TotalParts, file.Size, partsJSON, file.CreatedAt, erasureJSON,
+			nullIfEmpty(file.Bucket), nullIfEmpty(file.Key), file.Tus).
 		Suffix(`
 					ON CONFLICT (id) DO UPDATE
 					SET file_name   = EXCLUDED.file_name,
 						total_parts = EXCLUDED.total_parts,
 						size        = EXCLUDED.size,
-						parts       = EXCLUDED.parts`).
+						parts       = EXCLUDED.parts,
+						erasure     = EXCLUDED.erasure`).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("build upsert sql: %w", err)
+		return "", nil, fmt.Errorf("build upsert sql: %w", err)
 	}
 
-	// Выполнение
-	if _, err := s.pool.Exec(ctx, sqlStr, args...); err != nil {
-		return fmt.Errorf("exec upsert: %w", err)
-	}
+	return sqlStr, args, nil
+}
 
-	return nil
+// nullIfEmpty превращает пустую строку в NULL.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
 }
@@ -0,0 +1,57 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const storageNodesTable = "storage_nodes"
+
+// ListStorageNodes возвращает состав кластера в порядке добавления узлов.
+func (s *PGStore) ListStorageNodes(ctx context.Context) ([]models.StorageNode, error) {
+	rows, err := s.pool.Query(ctx, `SELECT url, state, created_at, updated_at FROM `+storageNodesTable+` ORDER BY created_at, url`)
+	if err != nil {
+		return nil, fmt.Errorf("query storage nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var out []models.StorageNode
+	for rows.Next() {
+		var node models.StorageNode
+		if err = rows.Scan(&node.URL, &node.State, &node.CreatedAt, &node.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan storage node: %w", err)
+		}
+		out = append(out, node)
+	}
+
+	return out, rows.Err()
+}
+
+// PutStorageNode добавляет узел или меняет состояние существующего, сохраняя время добавления.
+func (s *PGStore) PutStorageNode(ctx context.Context, node models.StorageNode) (models.StorageNode, error) {
+	err := s.pool.QueryRow(ctx, `INSERT INTO `+storageNodesTable+` (url, state, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (url) DO UPDATE SET state = EXCLUDED.state, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at`, node.URL, string(node.State), node.UpdatedAt).
+		Scan(&node.CreatedAt, &node.UpdatedAt)
+	if err != nil {
+		return models.StorageNode{}, fmt.Errorf("upsert storage node: %w", err)
+	}
+
+	return node, nil
+}
+
+// DeleteStorageNode убирает узел из состава кластера.
+func (s *PGStore) DeleteStorageNode(ctx context.Context, url string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM `+storageNodesTable+` WHERE url = $1`, url)
+	if err != nil {
+		return fmt.Errorf("exec storage node delete: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrStorageNotFound
+	}
+
+	return nil
+}
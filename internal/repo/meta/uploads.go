@@ -0,0 +1,291 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const (
+	uploadsTable     = "uploads"
+	uploadPartsTable = "upload_parts"
+)
+
+// CreateUpload регистрирует новую сессию составной загрузки.
+func (s *PGStore) CreateUpload(ctx context.Context, upload models.Upload) error {
+	var erasureJSON []byte
+	if upload.Erasure != nil {
+		var err error
+		if erasureJSON, err = json.Marshal(upload.Erasure); err != nil {
+			return fmt.Errorf("marshal erasure: %w", err)
+		}
+	}
+
+	var expiresAt *time.Time
+	if !upload.ExpiresAt.IsZero() {
+		expiresAt = &upload.ExpiresAt
+	}
+
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Insert(uploadsTable).
+		Columns("id", "file_name", "length", "metadata", "erasure", "created_at", "expires_at", "bucket", "key", "tus").
+		Values(upload.ID, upload.Name, upload.Length, upload.Metadata, erasureJSON, upload.CreatedAt, expiresAt, upload.Bucket, upload.Key, upload.Tus).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build upload insert: %w", err)
+	}
+
+	if _, err = s.pool.Exec(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("exec upload insert: %w", err)
+	}
+
+	return nil
+}
+
+// GetUpload возвращает сессию вместе с принятыми частями.
+func (s *PGStore) GetUpload(ctx context.Context, id string) (models.Upload, error) {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("file_name", "length", "metadata", "erasure", "created_at", "expires_at", "bucket", "key", "tus").
+		From(uploadsTable).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return models.Upload{}, fmt.Errorf("build upload select: %w", err)
+	}
+
+	upload := models.Upload{ID: id, Parts: make(map[int]models.Part)}
+	var (
+		erasureRaw []byte
+		expiresAt  *time.Time
+	)
+	if err = s.pool.QueryRow(ctx, sqlStr, args...).Scan(&upload.Name, &upload.Length, &upload.Metadata, &erasureRaw, &upload.CreatedAt, &expiresAt, &upload.Bucket, &upload.Key, &upload.Tus); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Upload{}, models.ErrUploadNotFound
+		}
+		return models.Upload{}, fmt.Errorf("scan upload row: %w", err)
+	}
+	if expiresAt != nil {
+		upload.ExpiresAt = *expiresAt
+	}
+	if len(erasureRaw) > 0 {
+		if err = json.Unmarshal(erasureRaw, &upload.Erasure); err != nil {
+			return models.Upload{}, fmt.Errorf("unmarshal erasure: %w", err)
+		}
+	}
+
+	sqlStr, args, err = sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("part").
+		From(uploadPartsTable).
+		Where(sq.Eq{"upload_id": id}).
+		ToSql()
+	if err != nil {
+		return models.Upload{}, fmt.Errorf("build upload parts select: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, sqlStr, args...)
+	if err != nil {
+		return models.Upload{}, fmt.Errorf("query upload parts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			raw  []byte
+			part models.Part
+		)
+		if err = rows.Scan(&raw); err != nil {
+			return models.Upload{}, fmt.Errorf("scan upload part: %w", err)
+		}
+		if err = json.Unmarshal(raw, &part); err != nil {
+			return models.Upload{}, fmt.Errorf("unmarshal upload part: %w", err)
+		}
+		upload.Parts[part.Index] = part
+	}
+
+	return upload, rows.Err()
+}
+
+// ListExpiredUploads возвращает до limit сессий, срок которых истёк к моменту now, вместе с частями.
+func (s *PGStore) ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]models.Upload, error) {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("id").
+		From(uploadsTable).
+		Where(sq.LtOrEq{"expires_at": now}).
+		OrderBy("expires_at").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build expired uploads select: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query expired uploads: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan expired uploads: %w", err)
+	}
+
+	out := make([]models.Upload, 0, len(ids))
+	for _, id := range ids {
+		upload, err := s.GetUpload(ctx, id)
+		if errors.Is(err, models.ErrUploadNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, upload)
+	}
+
+	return out, nil
+}
+
+// PutUploadPart записывает часть сессии и возвращает ту, которую она заменила.
+// Строка сессии блокируется на время транзакции, чтобы не принять часть в уже удалённую сессию.
+func (s *PGStore) PutUploadPart(ctx context.Context, id string, part models.Part) (models.Part, bool, error) {
+	partJSON, err := json.Marshal(part)
+	if err != nil {
+		return models.Part{}, false, fmt.Errorf("marshal part: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return models.Part{}, false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var one int
+	err = tx.QueryRow(ctx, `SELECT 1 FROM `+uploadsTable+` WHERE id = $1 FOR SHARE`, id).Scan(&one)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Part{}, false, models.ErrUploadNotFound
+		}
+		return models.Part{}, false, fmt.Errorf("lock upload: %w", err)
+	}
+
+	var (
+		prev     models.Part
+		replaced bool
+		prevRaw  []byte
+	)
+	err = tx.QueryRow(ctx,
+		`SELECT part FROM `+uploadPartsTable+` WHERE upload_id = $1 AND part_index = $2 FOR UPDATE`,
+		id, part.Index).Scan(&prevRaw)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(prevRaw, &prev); err != nil {
+			return models.Part{}, false, fmt.Errorf("unmarshal upload part: %w", err)
+		}
+		replaced = true
+	case !errors.Is(err, pgx.ErrNoRows):
+		return models.Part{}, false, fmt.Errorf("select upload part: %w", err)
+	}
+
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Insert(uploadPartsTable).
+		Columns("upload_id", "part_index", "part").
+		Values(id, part.Index, partJSON).
+		Suffix(`
+					ON CONFLICT (upload_id, part_index) DO UPDATE
+					SET part       = EXCLUDED.part,
+						updated_at = now()`).
+		ToSql()
+	if err != nil {
+		return models.Part{}, false, fmt.Errorf("build upload part upsert: %w", err)
+	}
+	if _, err = tx.Exec(ctx, sqlStr, args...); err != nil {
+		return models.Part{}, false, fmt.Errorf("exec upload part upsert: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Part{}, false, err
+	}
+
+	return prev, replaced, nil
+}
+
+// DeleteUpload удаляет сессию; её части уходят каскадом.
+func (s *PGStore) DeleteUpload(ctx context.Context, id string) error {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Delete(uploadsTable).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build upload delete: %w", err)
+	}
+
+	tag, err := s.pool.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		return fmt.Errorf("exec upload delete: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrUploadNotFound
+	}
+
+	return nil
+}
+
+// LockUpload берёт или продлевает на ttl аренду дозаписи в сессию для owner.
+// Срок считается по часам базы, поэтому реплики с расходящимися часами не отнимают аренду друг у друга.
+// models.ErrUploadLocked, если действующая аренда принадлежит другому владельцу.
+func (s *PGStore) LockUpload(ctx context.Context, id, owner string, ttl time.Duration) error {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Update(uploadsTable).
+		Set("lock_owner", owner).
+		Set("locked_until", sq.Expr("now() + ?::interval", ttl)).
+		Where(sq.Eq{"id": id}).
+		Where(sq.Or{
+			sq.Eq{"lock_owner": []string{"", owner}},
+			sq.Expr("locked_until IS NULL OR locked_until <= now()"),
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build upload lock: %w", err)
+	}
+
+	tag, err := s.pool.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		return fmt.Errorf("exec upload lock: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	var one int
+	err = s.pool.QueryRow(ctx, `SELECT 1 FROM `+uploadsTable+` WHERE id = $1`, id).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrUploadNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("select upload: %w", err)
+	}
+
+	return models.ErrUploadLocked
+}
+
+// UnlockUpload снимает аренду дозаписи, если она принадлежит owner.
+func (s *PGStore) UnlockUpload(ctx context.Context, id, owner string) error {
+	sqlStr, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Update(uploadsTable).
+		Set("lock_owner", "").
+		Set("locked_until", nil).
+		Where(sq.Eq{"id": id, "lock_owner": owner}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build upload unlock: %w", err)
+	}
+
+	if _, err = s.pool.Exec(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("exec upload unlock: %w", err)
+	}
+
+	return nil
+}
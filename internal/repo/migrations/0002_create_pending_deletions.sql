@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS pending_deletions (
+	file_id TEXT NOT NULL,
+	storage TEXT NOT NULL,
+	part_index INTEGER NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (file_id, storage, part_index)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS pending_deletions;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE files_meta ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS files_meta_created_idx ON files_meta (created_at, id);
+CREATE INDEX IF NOT EXISTS files_meta_size_idx ON files_meta (size, id);
+CREATE INDEX IF NOT EXISTS files_meta_name_prefix_idx ON files_meta (file_name text_pattern_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS files_meta_name_prefix_idx;
+DROP INDEX IF EXISTS files_meta_size_idx;
+DROP INDEX IF EXISTS files_meta_created_idx;
+ALTER TABLE files_meta DROP COLUMN IF EXISTS created_at;
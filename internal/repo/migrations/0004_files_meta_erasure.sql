@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE files_meta ADD COLUMN IF NOT EXISTS erasure JSONB;
+
+-- +goose Down
+ALTER TABLE files_meta DROP COLUMN IF EXISTS erasure;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS uploads (
+	id TEXT PRIMARY KEY,
+	file_name TEXT NOT NULL DEFAULT '',
+	erasure JSONB,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS upload_parts (
+	upload_id TEXT NOT NULL REFERENCES uploads (id) ON DELETE CASCADE,
+	part_index INTEGER NOT NULL,
+	part JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (upload_id, part_index)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS upload_parts;
+DROP TABLE IF EXISTS uploads;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS length BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS metadata TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE uploads DROP COLUMN IF EXISTS metadata;
+ALTER TABLE uploads DROP COLUMN IF EXISTS length;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS files_meta_name_idx ON files_meta ((file_name COLLATE "C"), id);
+
+-- +goose Down
+DROP INDEX IF EXISTS files_meta_name_idx;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS buckets (
+	name TEXT PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE files_meta ADD COLUMN IF NOT EXISTS bucket TEXT REFERENCES buckets (name);
+ALTER TABLE files_meta ADD COLUMN IF NOT EXISTS key TEXT;
+ALTER TABLE files_meta ADD CONSTRAINT files_meta_object_check CHECK ((bucket IS NULL) = (key IS NULL));
+CREATE UNIQUE INDEX IF NOT EXISTS files_meta_object_idx ON files_meta (bucket, (key COLLATE "C")) WHERE bucket IS NOT NULL;
+
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS bucket TEXT NOT NULL DEFAULT '';
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS key TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE uploads DROP COLUMN IF EXISTS key;
+ALTER TABLE uploads DROP COLUMN IF EXISTS bucket;
+DROP INDEX IF EXISTS files_meta_object_idx;
+ALTER TABLE files_meta DROP CONSTRAINT IF EXISTS files_meta_object_check;
+ALTER TABLE files_meta DROP COLUMN IF EXISTS key;
+ALTER TABLE files_meta DROP COLUMN IF EXISTS bucket;
+DROP TABLE IF EXISTS buckets;
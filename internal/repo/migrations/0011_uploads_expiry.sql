@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+UPDATE uploads SET expires_at = created_at + INTERVAL '24 hours' WHERE expires_at IS NULL;
+CREATE INDEX IF NOT EXISTS uploads_expires_at_idx ON uploads (expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS uploads_expires_at_idx;
+ALTER TABLE uploads DROP COLUMN IF EXISTS expires_at;
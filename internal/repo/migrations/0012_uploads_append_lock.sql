@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS lock_owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE uploads DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE uploads DROP COLUMN IF EXISTS lock_owner;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS tus BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE files_meta ADD COLUMN IF NOT EXISTS tus BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE files_meta DROP COLUMN IF EXISTS tus;
+ALTER TABLE uploads DROP COLUMN IF EXISTS tus;
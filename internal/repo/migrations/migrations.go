@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+const (
+	annotationUp             = "-- +goose Up"
+	annotationDown           = "-- +goose Down"
+	annotationStatementBegin = "-- +goose StatementBegin"
+	annotationStatementEnd   = "-- +goose StatementEnd"
+)
+
+// Migration описывает одну версию схемы: SQL для наката и отката.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load разбирает встроенные SQL-файлы (формат goose) и возвращает миграции по возрастанию версии.
+func Load() ([]Migration, error) {
+	return load(files)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Migration, 0, len(names))
+	seen := make(map[int64]string, len(names))
+	for _, name := range names {
+		version, err := parseVersion(name)
+		if err != nil {
+			return nil, err
+		}
+		if prev, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migration version %d is duplicated in %s and %s", version, prev, name)
+		}
+		seen[version] = name
+
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		up, down, err := parseSections(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		out = append(out, Migration{
+			Version: version,
+			Name:    name,
+			Up:      up,
+			Down:    down,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Version < out[j].Version
+	})
+
+	return out, nil
+}
+
+// parseVersion извлекает номер версии из имени вида 0001_create_files_meta.sql.
+func parseVersion(name string) (int64, error) {
+	prefix, _, ok := strings.Cut(name, "_")
+	if !ok {
+		return 0, fmt.Errorf("migration %s: name must look like <version>_<title>.sql", name)
+	}
+
+	v, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("migration %s: invalid version %q", name, prefix)
+	}
+
+	return v, nil
+}
+
+// parseSections делит файл на Up/Down секции по аннотациям goose.
+func parseSections(src string) (string, string, error) {
+	var (
+		up, down strings.Builder
+		current  *strings.Builder
+	)
+
+	sc := bufio.NewScanner(strings.NewReader(src))
+	for sc.Scan() {
+		line := sc.Text()
+		switch strings.TrimSpace(line) {
+		case annotationUp:
+			current = &up
+			continue
+		case annotationDown:
+			current = &down
+			continue
+		case annotationStatementBegin, annotationStatementEnd:
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	if err := sc.Err(); err != nil {
+		return "", "", err
+	}
+
+	if strings.TrimSpace(up.String()) == "" {
+		return "", "", fmt.Errorf("missing %q section", annotationUp)
+	}
+
+	return strings.TrimSpace(up.String()), strings.TrimSpace(down.String()), nil
+}
@@ -3,75 +3,36 @@ package adapters
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"sort"
 	"strings"
 	"time"
 )
 
-var healthHTTPClient = &http.Client{Timeout: 2 * time.Second}
-
-// HealthAdapter определяет готовность стораджей по их health-эндпоинтам
-type HealthAdapter struct {
-	MaxStorageLoadBytes int64
-}
-
-// NewHealthAdapter инициализирует адаптер доступности
-func NewHealthAdapter(maxLoad int64) *HealthAdapter {
-	return &HealthAdapter{
-		MaxStorageLoadBytes: maxLoad,
-	}
-}
-
-// Available возвращает отсортированный список готовых стораджей
-func (a *HealthAdapter) Available(ctx context.Context, storages []string) []string {
-	if len(storages) == 0 {
-		return nil
-	}
+// DefaultMinFreeBytes — порог свободного места по умолчанию, ниже которого сторадж не получает новых частей.
+const DefaultMinFreeBytes int64 = 1 << 30
 
-	type candidate struct {
-		base string
-		load int64
-	}
-
-	ready := make([]candidate, 0, len(storages))
-	for _, base := range storages {
-		info, err := fetchStorageHealth(ctx, base)
-		if err != nil || !info.OK {
-			continue
-		}
-		if !a.loadAcceptable(info.TotalBytes) {
-			continue
-		}
-		ready = append(ready, candidate{
-			base: base,
-			load: info.TotalBytes,
-		})
-	}
-
-	sort.Slice(ready, func(i, j int) bool {
-		return ready[i].load < ready[j].load
-	})
+var healthHTTPClient = &http.Client{Timeout: 2 * time.Second}
 
-	result := make([]string, len(ready))
-	for i, c := range ready {
-		result[i] = c.base
-	}
-	return result
-}
+// errUnhealthy — узел ответил на health, но кодом ошибки.
+var errUnhealthy = errors.New("health check failed")
 
-func (a *HealthAdapter) loadAcceptable(load int64) bool {
-	if a.MaxStorageLoadBytes <= 0 {
+// hasRoom проверяет порог свободного места. Узел, не сообщивший ёмкость диска (total_bytes == 0), не исключается.
+func hasRoom(info storageHealth, minFree int64) bool {
+	if minFree <= 0 || info.TotalBytes <= 0 {
 		return true
 	}
-	return load <= a.MaxStorageLoadBytes
+	return info.FreeBytes >= minFree
 }
 
 type storageHealth struct {
 	OK         bool  `json:"ok"`
 	FreeBytes  int64 `json:"free_bytes"`
 	TotalBytes int64 `json:"total_bytes"`
+	UsedBytes  int64 `json:"used_bytes"`
+	Parts      int64 `json:"parts"`
+	Files      int64 `json:"files"`
 }
 
 func fetchStorageHealth(ctx context.Context, base string) (payload storageHealth, err error) {
@@ -87,7 +48,7 @@ func fetchStorageHealth(ctx context.Context, base string) (payload storageHealth
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return storageHealth{}, fmt.Errorf("health check failed: %s", resp.Status)
+		return storageHealth{}, fmt.Errorf("%w: %s", errUnhealthy, resp.Status)
 	}
 
 	if err = json.NewDecoder(resp.Body).Decode(&payload); err != nil {
@@ -0,0 +1,351 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Состояния стоража в HealthMonitor.
+const (
+	StateUnknown = "unknown"
+	StateUp      = "up"
+	// StateDegraded — узел отвечает, но не готов принимать части: health не ok или свободного места меньше порога.
+	StateDegraded = "degraded"
+	StateDown     = "down"
+)
+
+const (
+	// DefaultHealthInterval — период опроса стораджей по умолчанию.
+	DefaultHealthInterval = 5 * time.Second
+	// defaultRise и defaultFall — сколько одинаковых результатов подряд нужно, чтобы узел
+	// улучшил или ухудшил состояние: единичный сбой или всплеск не дёргает маршрутизацию.
+	defaultRise = 2
+	defaultFall = 2
+)
+
+// NodeStatus — последнее известное состояние стоража.
+type NodeStatus struct {
+	URL        string    `json:"url"`
+	State      string    `json:"state"`
+	LatencyMs  int64     `json:"latency_ms"`
+	FreeBytes  int64     `json:"free_bytes"`
+	TotalBytes int64     `json:"total_bytes"`
+	UsedBytes  int64     `json:"used_bytes"`
+	Parts      int64     `json:"parts"`
+	Files      int64     `json:"files"`
+	CheckedAt  time.Time `json:"checked_at,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type nodeState struct {
+	status NodeStatus
+	// pending — состояние, к которому склоняется узел, streak — сколько результатов подряд за него.
+	pending string
+	streak  int
+	// checking — фоновая проверка узла ещё не завершилась.
+	checking bool
+}
+
+// HealthMonitor опрашивает стораджи в фоне и отдаёт готовые узлы из кеша,
+// так что выбор стораджей для загрузки не ждёт health-запросов.
+type HealthMonitor struct {
+	// MinFreeBytes — сколько места должно оставаться на диске стоража; 0 и меньше — без проверки.
+	MinFreeBytes int64
+	Interval     time.Duration
+	Rise, Fall   int
+
+	mu    sync.Mutex
+	nodes map[string]*nodeState
+	order []string
+	// polling — фоновые проверки, которые ждёт остановка монитора.
+	polling sync.WaitGroup
+}
+
+// NewHealthMonitor создаёт монитор. minFree == 0 — порог по умолчанию, отрицательное значение
+// отключает проверку свободного места; interval <= 0 — DefaultHealthInterval.
+func NewHealthMonitor(minFree int64, interval time.Duration) *HealthMonitor {
+	if minFree == 0 {
+		minFree = DefaultMinFreeBytes
+	}
+	if interval <= 0 {
+		interval = DefaultHealthInterval
+	}
+
+	return &HealthMonitor{
+		MinFreeBytes: minFree,
+		Interval:     interval,
+		Rise:         defaultRise,
+		Fall:         defaultFall,
+		nodes:        map[string]*nodeState{},
+	}
+}
+
+// Start запускает фоновый опрос всех наблюдаемых узлов и возвращает функцию остановки.
+func (m *HealthMonitor) Start() func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(m.Interval)
+		defer ticker.Stop()
+		for {
+			m.poll(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			m.polling.Wait()
+		})
+	}
+}
+
+// Watch добавляет узлы под наблюдение; их состояние неизвестно до первой проверки.
+func (m *HealthMonitor) Watch(storages ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, base := range storages {
+		base = strings.TrimSpace(base)
+		if base == "" {
+			continue
+		}
+		if _, ok := m.nodes[base]; ok {
+			continue
+		}
+		m.nodes[base] = &nodeState{status: NodeStatus{URL: base, State: StateUnknown}}
+		m.order = append(m.order, base)
+	}
+}
+
+// Track заменяет набор наблюдаемых узлов: новые добавляются, отсутствующие в storages
+// перестают опрашиваться.
+func (m *HealthMonitor) Track(storages []string) {
+	m.Watch(storages...)
+
+	keep := make(map[string]struct{}, len(storages))
+	for _, base := range storages {
+		keep[strings.TrimSpace(base)] = struct{}{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	order := m.order[:0]
+	for _, base := range m.order {
+		if _, ok := keep[base]; ok {
+			order = append(order, base)
+			continue
+		}
+		delete(m.nodes, base)
+	}
+	m.order = order
+}
+
+// Available возвращает готовые стораджи из storages, начиная с наименее загруженных.
+// Узлы, которые ещё ни разу не проверялись, проверяются сразу.
+func (m *HealthMonitor) Available(ctx context.Context, storages []string) []string {
+	if len(storages) == 0 {
+		return nil
+	}
+
+	m.Watch(storages...)
+	var unknown []string
+	m.mu.Lock()
+	for _, base := range storages {
+		if st, ok := m.nodes[base]; ok && st.status.State == StateUnknown {
+			unknown = append(unknown, base)
+		}
+	}
+	m.mu.Unlock()
+	m.checkAll(ctx, unknown)
+
+	type candidate struct {
+		base string
+		load int64
+	}
+
+	m.mu.Lock()
+	ready := make([]candidate, 0, len(storages))
+	for _, base := range storages {
+		if st, ok := m.nodes[base]; ok && st.status.State == StateUp {
+			ready = append(ready, candidate{base: base, load: st.status.UsedBytes})
+		}
+	}
+	m.mu.Unlock()
+
+	sort.SliceStable(ready, func(i, j int) bool {
+		return ready[i].load < ready[j].load
+	})
+
+	result := make([]string, len(ready))
+	for i, c := range ready {
+		result[i] = c.base
+	}
+	return result
+}
+
+// Status возвращает состояние узла; неизвестный монитору узел — в состоянии unknown.
+func (m *HealthMonitor) Status(base string) NodeStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if st, ok := m.nodes[base]; ok {
+		return st.status
+	}
+	return NodeStatus{URL: base, State: StateUnknown}
+}
+
+// Statuses возвращает состояние наблюдаемых узлов в порядке их добавления.
+func (m *HealthMonitor) Statuses() []NodeStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]NodeStatus, 0, len(m.order))
+	for _, base := range m.order {
+		out = append(out, m.nodes[base].status)
+	}
+	return out
+}
+
+// ReportFailure учитывает сбой обращения к стораджу вне опроса (например, обрыв соединения при записи части)
+// наравне с неудачной проверкой: узел уходит в down, не дожидаясь следующих опросов.
+func (m *HealthMonitor) ReportFailure(base string, err error) {
+	m.observe(base, probeResult{state: StateDown, err: err.Error()})
+}
+
+// poll запускает проверки всех узлов, не дожидаясь их: узел, чья прошлая проверка ещё идёт,
+// пропускается, так что зависший сторадж не задерживает опрос остальных.
+func (m *HealthMonitor) poll(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, base := range m.order {
+		st := m.nodes[base]
+		if st.checking {
+			continue
+		}
+		st.checking = true
+		m.polling.Add(1)
+		go func(base string, st *nodeState) {
+			defer m.polling.Done()
+			m.check(ctx, base)
+			m.mu.Lock()
+			st.checking = false
+			m.mu.Unlock()
+		}(base, st)
+	}
+}
+
+// checkAll проверяет узлы параллельно и ждёт все результаты.
+func (m *HealthMonitor) checkAll(ctx context.Context, storages []string) {
+	var wg sync.WaitGroup
+	for _, base := range storages {
+		wg.Add(1)
+		go func(base string) {
+			defer wg.Done()
+			m.check(ctx, base)
+		}(base)
+	}
+	wg.Wait()
+}
+
+// probeResult — итог проверки узла или сообщения о сбое.
+type probeResult struct {
+	state string
+	err   string
+	// at и latency заданы только у проверок; info — только если узел ответил на health.
+	at      time.Time
+	latency time.Duration
+	info    *storageHealth
+}
+
+func (m *HealthMonitor) check(ctx context.Context, base string) {
+	started := time.Now()
+	info, err := fetchStorageHealth(ctx, base)
+	if err != nil && ctx.Err() != nil {
+		// Проверку прервали (остановка монитора, отмена запроса) — это ничего не говорит об узле.
+		return
+	}
+
+	res := probeResult{state: StateUp, at: started.UTC(), latency: time.Since(started)}
+	switch {
+	case errors.Is(err, errUnhealthy):
+		res.state, res.err = StateDegraded, err.Error()
+	case err != nil:
+		res.state, res.err = StateDown, err.Error()
+	case !info.OK:
+		res.state, res.err = StateDegraded, "storage reports not ok"
+	case !hasRoom(info, m.MinFreeBytes):
+		res.state, res.err = StateDegraded, "free space below threshold"
+	}
+	if err == nil {
+		res.info = &info
+	}
+	m.observe(base, res)
+}
+
+// observe применяет результат с гистерезисом: состояние меняется, только когда новое
+// держится Rise (к лучшему) или Fall (к худшему) результатов подряд. Первая проверка
+// узла в неизвестном состоянии применяется сразу. Ёмкость не ответившего узла
+// остаётся последней известной.
+func (m *HealthMonitor) observe(base string, res probeResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.nodes[base]
+	if !ok {
+		return
+	}
+	st.status.Error = res.err
+	if !res.at.IsZero() {
+		st.status.CheckedAt, st.status.LatencyMs = res.at, res.latency.Milliseconds()
+	}
+	if info := res.info; info != nil {
+		st.status.FreeBytes, st.status.TotalBytes, st.status.UsedBytes = info.FreeBytes, info.TotalBytes, info.UsedBytes
+		st.status.Parts, st.status.Files = info.Parts, info.Files
+	}
+
+	state := res.state
+	switch {
+	case st.status.State == StateUnknown:
+		st.status.State, st.pending, st.streak = state, "", 0
+		return
+	case st.status.State == state:
+		st.pending, st.streak = "", 0
+		return
+	case st.pending != state:
+		st.pending, st.streak = state, 0
+	}
+
+	st.streak++
+	need := m.Fall
+	if stateRank(state) > stateRank(st.status.State) {
+		need = m.Rise
+	}
+	if st.streak >= need {
+		st.status.State, st.pending, st.streak = state, "", 0
+	}
+}
+
+func stateRank(state string) int {
+	switch state {
+	case StateUp:
+		return 2
+	case StateDegraded:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,49 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/sir_venger/s3_lite/pkg/storageclient"
+)
+
+// observedClient сообщает монитору о сетевых сбоях обращений к стораджам,
+// чтобы недоступный узел выпадал из выбора сразу, а не через несколько опросов.
+type observedClient struct {
+	storageclient.Client
+	monitor *HealthMonitor
+}
+
+// ObserveClient оборачивает клиент стораджей, передавая сетевые ошибки в монитор.
+func ObserveClient(cli storageclient.Client, monitor *HealthMonitor) storageclient.Client {
+	return &observedClient{Client: cli, monitor: monitor}
+}
+
+func (c *observedClient) PutPart(ctx context.Context, baseURL string, req storageclient.PutPartRequest) error {
+	err := c.Client.PutPart(ctx, baseURL, req)
+	c.report(ctx, baseURL, err)
+	return err
+}
+
+func (c *observedClient) GetPart(ctx context.Context, baseURL, fileID string, index int) (io.ReadCloser, error) {
+	rc, err := c.Client.GetPart(ctx, baseURL, fileID, index)
+	c.report(ctx, baseURL, err)
+	return rc, err
+}
+
+func (c *observedClient) GetPartRange(ctx context.Context, baseURL, fileID string, index int, offset, length int64) (io.ReadCloser, error) {
+	rc, err := c.Client.GetPartRange(ctx, baseURL, fileID, index, offset, length)
+	c.report(ctx, baseURL, err)
+	return rc, err
+}
+
+// report учитывает только сетевые ошибки: ответы узла с кодом ошибки (404, 409) говорят о данных, а не о его доступности.
+func (c *observedClient) report(ctx context.Context, baseURL string, err error) {
+	var opErr *net.OpError
+	if err == nil || ctx.Err() != nil || !errors.As(err, &opErr) {
+		return
+	}
+	c.monitor.ReportFailure(baseURL, err)
+}
@@ -0,0 +1,86 @@
+package filesvc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// CreateAPIKey выпускает ключ с правами perms и возвращает его вместе с токеном "<id>.<secret>".
+// Секрет отдаётся один раз: в метаданных хранится только его хеш.
+func (s *Files) CreateAPIKey(ctx context.Context, name string, perms []models.Permission) (models.APIKey, string, error) {
+	if len(perms) == 0 {
+		return models.APIKey{}, "", fmt.Errorf("%w: permissions list is empty", models.ErrInvalidPermission)
+	}
+	for _, p := range perms {
+		if !models.ValidPermission(p) {
+			return models.APIKey{}, "", fmt.Errorf("%w: unknown permission %q", models.ErrInvalidPermission, p)
+		}
+	}
+
+	id, secret, err := newAPIToken()
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	key := models.APIKey{
+		ID:          id,
+		Name:        name,
+		SecretHash:  models.HashSecret(secret),
+		Permissions: perms,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err = s.Keys.CreateAPIKey(ctx, key); err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	return key, id + "." + secret, nil
+}
+
+// ListAPIKeys возвращает выпущенные ключи.
+func (s *Files) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.Keys.ListAPIKeys(ctx)
+}
+
+// DeleteAPIKey отзывает ключ.
+func (s *Files) DeleteAPIKey(ctx context.Context, id string) error {
+	return s.Keys.DeleteAPIKey(ctx, id)
+}
+
+// AuthenticateAPIKey находит ключ по токену "<id>.<secret>"; сверяется sha256 секрета.
+// models.ErrUnauthorized, если токен неверен или ключ отозван.
+func (s *Files) AuthenticateAPIKey(ctx context.Context, token string) (models.APIKey, error) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id == "" || secret == "" {
+		return models.APIKey{}, models.ErrUnauthorized
+	}
+
+	key, err := s.Keys.GetAPIKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, models.ErrKeyNotFound) {
+			return models.APIKey{}, models.ErrUnauthorized
+		}
+		return models.APIKey{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(models.HashSecret(secret)), []byte(key.SecretHash)) != 1 {
+		return models.APIKey{}, models.ErrUnauthorized
+	}
+
+	return key, nil
+}
+
+// newAPIToken генерирует идентификатор и секрет нового ключа.
+func newAPIToken() (id, secret string, err error) {
+	buf := make([]byte, 8+32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(buf[:8]), hex.EncodeToString(buf[8:]), nil
+}
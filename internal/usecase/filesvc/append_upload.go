@@ -0,0 +1,154 @@
+package filesvc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const (
+	// appendLockTTL — срок аренды дозаписи; держатель продлевает её каждые appendLockRenew.
+	appendLockTTL   = 30 * time.Second
+	appendLockRenew = appendLockTTL / 3
+)
+
+// AppendUpload дописывает поток в конец сессии с объявленной длиной (протокол tus) и возвращает новое смещение.
+// offset должен совпадать с числом уже принятых байт. Тело режется на части не больше предельного размера,
+// каждая часть пишется на стораджи сразу; при обрыве соединения принятые байты сохраняются.
+// С непустым checksum (hex sha256 всего тела) тело должно уместиться в одну часть и при несовпадении
+// не сохраняется вовсе. Когда принят весь объём, сессия завершается в файл с тем же идентификатором.
+// Дозапись идёт под арендой в хранилище метаданных, поэтому в одну сессию пишет не больше одного запроса
+// среди всех реплик REST; остальные получают models.ErrUploadLocked.
+func (s *Files) AppendUpload(ctx context.Context, uploadID string, offset int64, r io.Reader, checksum string) (int64, error) {
+	lost, unlock, err := s.lockAppend(ctx, uploadID)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return 0, err
+	}
+	if !upload.Tus || upload.Length <= 0 {
+		return 0, fmt.Errorf("%w: not a tus upload with a declared length", models.ErrInvalidPart)
+	}
+	received := upload.Received()
+	if offset != received {
+		return received, fmt.Errorf("%w: upload is at %d, got %d", models.ErrOffset, received, offset)
+	}
+
+	var (
+		body    = bufio.NewReader(r)
+		readErr error
+		number  = len(upload.Parts) + 1
+		limit   = s.maxUploadPartSize(upload.Erasure)
+	)
+	if checksum == "" {
+		// Обрыв тела не отменяет уже прочитанное: сохраняем его последней частью и сообщаем об ошибке.
+		// Отключение клиента отменяет контекст запроса, поэтому запись на стораджи от него отвязана —
+		// остановит её конец (или обрыв) тела.
+		body = bufio.NewReader(&stickyReader{r: r, err: &readErr})
+		ctx = context.WithoutCancel(ctx)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		// Потеря аренды останавливает запись: сессию уже может дописывать другой запрос.
+		select {
+		case <-lost:
+			cancel(models.ErrUploadLocked)
+		case <-ctx.Done():
+		}
+	}()
+
+	for received < upload.Length {
+		if _, err = body.Peek(1); err != nil {
+			break
+		}
+
+		want := min(limit, upload.Length-received)
+		if checksum != "" {
+			want = upload.Length - received
+		}
+		part, err := s.UploadPart(ctx, upload.ID, number, io.LimitReader(body, want), checksum)
+		if err != nil {
+			if cause := context.Cause(ctx); errors.Is(cause, models.ErrUploadLocked) {
+				err = cause
+			}
+			return received, err
+		}
+		received += part.Size
+		number++
+	}
+	if readErr != nil {
+		return received, readErr
+	}
+
+	if received == upload.Length {
+		if _, err = s.CompleteUpload(ctx, upload.ID); err != nil {
+			return received, err
+		}
+	}
+
+	return received, nil
+}
+
+// lockAppend берёт аренду дозаписи в сессию и продлевает её в фоне до вызова unlock.
+// Канал lost закрывается, если продлить аренду не удалось.
+func (s *Files) lockAppend(ctx context.Context, uploadID string) (lost <-chan struct{}, unlock func(), err error) {
+	owner := uuid.NewString()
+	if err = s.Uploads.LockUpload(ctx, uploadID, owner, appendLockTTL); err != nil {
+		return nil, nil, err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	var (
+		lostCh = make(chan struct{})
+		done   = make(chan struct{})
+		exited = make(chan struct{})
+	)
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(appendLockRenew)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Uploads.LockUpload(ctx, uploadID, owner, appendLockTTL); err != nil {
+					close(lostCh)
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return lostCh, func() {
+		close(done)
+		<-exited
+		_ = s.Uploads.UnlockUpload(ctx, uploadID, owner)
+	}, nil
+}
+
+// stickyReader превращает ошибку чтения в io.EOF, запоминая её для вызывающего.
+type stickyReader struct {
+	r   io.Reader
+	err *error
+}
+
+func (s *stickyReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		*s.err = err
+		err = io.EOF
+	}
+	return n, err
+}
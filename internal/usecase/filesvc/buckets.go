@@ -0,0 +1,117 @@
+package filesvc
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+var errNoBuckets = fmt.Errorf("buckets are not supported by the meta store")
+
+// CreateBucket создаёт бакет с проверенным именем.
+func (s *Files) CreateBucket(ctx context.Context, name string) (models.Bucket, error) {
+	if s.Buckets == nil {
+		return models.Bucket{}, errNoBuckets
+	}
+	if !models.ValidBucketName(name) {
+		return models.Bucket{}, fmt.Errorf("%w: bucket name %q", models.ErrInvalidName, name)
+	}
+
+	return s.Buckets.CreateBucket(ctx, name)
+}
+
+// GetBucket возвращает бакет по имени.
+func (s *Files) GetBucket(ctx context.Context, name string) (models.Bucket, error) {
+	if s.Buckets == nil {
+		return models.Bucket{}, models.ErrBucketNotFound
+	}
+	return s.Buckets.GetBucket(ctx, name)
+}
+
+// ListBuckets возвращает все бакеты.
+func (s *Files) ListBuckets(ctx context.Context) ([]models.Bucket, error) {
+	if s.Buckets == nil {
+		return nil, nil
+	}
+	return s.Buckets.ListBuckets(ctx)
+}
+
+// DeleteBucket удаляет бакет; в нём не должно остаться объектов.
+func (s *Files) DeleteBucket(ctx context.Context, name string) error {
+	if s.Buckets == nil {
+		return models.ErrBucketNotFound
+	}
+	return s.Buckets.DeleteBucket(ctx, name)
+}
+
+// PutObject записывает тело под ключом key бакета. Прежний объект с тем же ключом
+// заменяется атомарно, его части удаляются со стораджей.
+func (s *Files) PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64) (models.UploadResult, error) {
+	if err := s.checkObject(ctx, bucket, key); err != nil {
+		return models.UploadResult{}, err
+	}
+
+	return s.upload(ctx, r, size, models.File{Name: key, Bucket: bucket, Key: key})
+}
+
+// StatObject возвращает описание объекта бакета.
+func (s *Files) StatObject(ctx context.Context, bucket, key string) (models.File, error) {
+	if s.Buckets == nil {
+		return models.File{}, models.ErrBucketNotFound
+	}
+	return s.Buckets.GetObject(ctx, bucket, key)
+}
+
+// DeleteObject удаляет объект бакета вместе с его частями.
+func (s *Files) DeleteObject(ctx context.Context, bucket, key string) error {
+	file, err := s.StatObject(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+
+	return s.Delete(ctx, file.ID)
+}
+
+// checkObject проверяет адрес объекта до записи частей: ключ допустим, бакет существует.
+func (s *Files) checkObject(ctx context.Context, bucket, key string) error {
+	if s.Buckets == nil {
+		return errNoBuckets
+	}
+	if !models.ValidObjectKey(key) {
+		return fmt.Errorf("%w: object key must be 1..%d bytes of UTF-8", models.ErrInvalidName, models.MaxObjectKeyLength)
+	}
+	_, err := s.Buckets.GetBucket(ctx, bucket)
+
+	return err
+}
+
+// commit сохраняет метаданные готового файла. Объект бакета заменяет прежний с тем же ключом,
+// а части заменённой версии удаляются (недоступные узлы — через очередь удаления).
+func (s *Files) commit(ctx context.Context, file models.File) error {
+	if file.Bucket == "" {
+		return s.MetaStorage.Save(ctx, file)
+	}
+	if s.Buckets == nil {
+		return errNoBuckets
+	}
+
+	prev, replaced, err := s.Buckets.PutObject(ctx, file)
+	if err != nil {
+		return err
+	}
+	// Повтор CompleteUpload записывает тот же файл ещё раз — его части трогать нельзя.
+	if !replaced || prev.ID == file.ID {
+		return nil
+	}
+
+	// Новая версия уже сохранена: сбой очереди не должен превращать запись в ошибку.
+	var pending []models.PendingDeletion
+	for _, part := range prev.Parts {
+		pending = append(pending, s.removePlacements(ctx, prev.ID, part.Placements())...)
+	}
+	_ = s.queueDeletions(ctx, pending)
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package filesvc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+)
+
+// partBuffer накапливает одну часть файла: первые memLimit байт в памяти, остальное — во временном файле.
+// Буфер можно перечитывать сколько угодно раз, что нужно для повторной отправки части.
+type partBuffer struct {
+	memLimit int64
+	spoolDir string
+
+	mem  bytes.Buffer
+	file *os.File
+	size int64
+	sha  hash.Hash
+}
+
+func newPartBuffer(memLimit int64, spoolDir string) *partBuffer {
+	return &partBuffer{
+		memLimit: memLimit,
+		spoolDir: spoolDir,
+		sha:      sha256.New(),
+	}
+}
+
+// fill дочитывает из r не более max байт. Возвращает io.EOF, если поток закончился раньше.
+func (b *partBuffer) fill(r io.Reader, max int64) (int64, error) {
+	n, err := io.Copy(b, io.LimitReader(r, max))
+	if err != nil {
+		return n, err
+	}
+	if n < max {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Write реализует io.Writer: данные сверх лимита памяти уходят во временный файл.
+func (b *partBuffer) Write(p []byte) (int, error) {
+	total := len(p)
+	if room := b.memLimit - int64(b.mem.Len()); b.file == nil && room > 0 {
+		chunk := p[:min(int64(len(p)), room)]
+		b.mem.Write(chunk)
+		b.sha.Write(chunk)
+		b.size += int64(len(chunk))
+		p = p[len(chunk):]
+	}
+	if len(p) == 0 {
+		return total, nil
+	}
+
+	if b.file == nil {
+		f, err := os.CreateTemp(b.spoolDir, "s3lite-part-*")
+		if err != nil {
+			return total - len(p), err
+		}
+		b.file = f
+	}
+
+	n, err := b.file.Write(p)
+	b.sha.Write(p[:n])
+	b.size += int64(n)
+
+	return total - len(p) + n, err
+}
+
+// Size возвращает число накопленных байт.
+func (b *partBuffer) Size() int64 {
+	return b.size
+}
+
+// Sha256 возвращает hex-контрольную сумму накопленных данных.
+func (b *partBuffer) Sha256() string {
+	return hex.EncodeToString(b.sha.Sum(nil))
+}
+
+// Reader возвращает новый поток чтения с начала буфера.
+func (b *partBuffer) Reader() io.Reader {
+	memReader := bytes.NewReader(b.mem.Bytes())
+	if b.file == nil {
+		return memReader
+	}
+
+	spilled := b.size - int64(b.mem.Len())
+	return io.MultiReader(memReader, io.NewSectionReader(b.file, 0, spilled))
+}
+
+// Close удаляет временный файл, если он создавался.
+func (b *partBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+
+	name := b.file.Name()
+	err := b.file.Close()
+	b.file = nil
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+
+	return err
+}
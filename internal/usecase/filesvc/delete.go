@@ -0,0 +1,139 @@
+package filesvc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// pendingRetryBatch ограничивает число частей, обрабатываемых за один проход повторного удаления.
+const pendingRetryBatch = 256
+
+// Delete удаляет метаданные файла и все копии (или шарды) его частей со стораджей.
+// Части на недоступных узлах ставятся в очередь и удаляются позже (см. RetryPendingDeletions).
+func (s *Files) Delete(ctx context.Context, fileID string) error {
+	file, err := s.MetaStorage.Get(ctx, fileID)
+	if err != nil {
+		return err
+	}
+
+	// Сначала убираем метаданные: файл сразу перестаёт быть доступен клиентам,
+	// даже если часть стораджей сейчас не отвечает.
+	if err = s.MetaStorage.Delete(ctx, fileID); err != nil {
+		return err
+	}
+
+	var pending []models.PendingDeletion
+	for _, part := range file.Parts {
+		pending = append(pending, s.removePlacements(ctx, file.ID, part.Placements())...)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if s.Deletions == nil {
+		return fmt.Errorf("delete %d parts of file %s: storages unavailable", len(pending), file.ID)
+	}
+
+	return s.queueDeletions(ctx, pending)
+}
+
+// removePlacements удаляет объекты со стораджей и возвращает те, что удалить не удалось.
+func (s *Files) removePlacements(ctx context.Context, fileID string, placements []models.Placement) []models.PendingDeletion {
+	var failed []models.PendingDeletion
+	for _, pl := range placements {
+		if err := s.StorageCli.DeletePart(ctx, pl.Storage, fileID, pl.Index); err != nil {
+			failed = append(failed, models.PendingDeletion{
+				FileID:    fileID,
+				Index:     pl.Index,
+				Storage:   pl.Storage,
+				Attempts:  1,
+				LastError: err.Error(),
+			})
+		}
+	}
+
+	return failed
+}
+
+// queueDeletions ставит объекты в очередь отложенного удаления.
+// Контекст запроса мог уже истечь — очередь должна сохраниться в любом случае.
+func (s *Files) queueDeletions(ctx context.Context, items []models.PendingDeletion) error {
+	if s.Deletions == nil || len(items) == 0 {
+		return nil
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	return s.Deletions.AddPendingDeletions(saveCtx, items)
+}
+
+// RetryPendingDeletions повторяет удаление частей из очереди и возвращает число успешно удалённых.
+func (s *Files) RetryPendingDeletions(ctx context.Context) (int, error) {
+	if s.Deletions == nil {
+		return 0, nil
+	}
+
+	items, err := s.Deletions.ListPendingDeletions(ctx, pendingRetryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		done   int
+		failed []models.PendingDeletion
+	)
+	for _, d := range items {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err = s.StorageCli.DeletePart(ctx, d.Storage, d.FileID, d.Index); err != nil {
+			d.Attempts++
+			d.LastError = err.Error()
+			failed = append(failed, d)
+			continue
+		}
+
+		if err = s.Deletions.RemovePendingDeletion(ctx, d); err != nil {
+			return done, err
+		}
+		done++
+	}
+
+	if err = s.Deletions.AddPendingDeletions(ctx, failed); err != nil {
+		return done, err
+	}
+
+	return done, ctx.Err()
+}
+
+// StartDeletionRetry периодически дочищает очередь отложенных удалений.
+func StartDeletionRetry(svc Service, every time.Duration) func() {
+	if svc == nil || every <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(every)
+	var once sync.Once
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = svc.RetryPendingDeletions(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(cancel)
+	}
+}
@@ -0,0 +1,322 @@
+package filesvc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/reedsolomon"
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/storageclient"
+)
+
+// DefaultErasureStripeSize — объём данных файла в одной полосе (части) в режиме erasure coding.
+const DefaultErasureStripeSize int64 = 8 << 20
+
+// erasureLayout возвращает схему кодирования новых загрузок или nil, если включена репликация.
+func (s *Files) erasureLayout() *models.ErasureLayout {
+	if s.ErasureDataShards <= 0 || s.ErasureParityShards <= 0 {
+		return nil
+	}
+	return &models.ErasureLayout{DataShards: s.ErasureDataShards, ParityShards: s.ErasureParityShards}
+}
+
+func (s *Files) erasureStripeSize() int64 {
+	if s.ErasureStripeSize <= 0 {
+		return DefaultErasureStripeSize
+	}
+	return s.ErasureStripeSize
+}
+
+// placement возвращает, на сколько различных узлов пишется каждая часть и сколько из них обязательно.
+// При erasure coding каждый шард полосы ложится на свой узел, обязательны узлы под кворум шардов.
+func (s *Files) placement(layout *models.ErasureLayout) (width, minimum int) {
+	if layout != nil {
+		return layout.DataShards + layout.ParityShards, s.erasureWriteQuorum(*layout)
+	}
+	return s.replicationFactor(), s.writeQuorum()
+}
+
+// erasureWriteQuorum — сколько шардов полосы должно записаться, чтобы часть считалась записанной:
+// не меньше k (иначе полосу не восстановить), по умолчанию k+1 — один шард запаса на чтение.
+func (s *Files) erasureWriteQuorum(layout models.ErasureLayout) int {
+	q := s.ErasureWriteQuorum
+	if q <= 0 {
+		q = layout.DataShards + 1
+	}
+	return min(max(q, layout.DataShards), layout.DataShards+layout.ParityShards)
+}
+
+// codec возвращает (и кэширует) кодировщик Reed–Solomon для схемы файла.
+func (s *Files) codec(layout models.ErasureLayout) (reedsolomon.Encoder, error) {
+	if enc, ok := s.codecs.Load(layout); ok {
+		return enc.(reedsolomon.Encoder), nil
+	}
+
+	enc, err := reedsolomon.New(layout.DataShards, layout.ParityShards)
+	if err != nil {
+		return nil, fmt.Errorf("erasure %d+%d: %w", layout.DataShards, layout.ParityShards, err)
+	}
+	actual, _ := s.codecs.LoadOrStore(layout, enc)
+
+	return actual.(reedsolomon.Encoder), nil
+}
+
+// shardIndex — номер, под которым шард j полосы stripe хранится на стораджах.
+func shardIndex(layout models.ErasureLayout, stripe, j int) int {
+	return stripe*(layout.DataShards+layout.ParityShards) + j
+}
+
+// storageTotal — сколько объектов файла лежит на стораджах в сумме (для X-Total-Parts).
+func storageTotal(layout *models.ErasureLayout, parts int) int {
+	if layout == nil {
+		return parts
+	}
+	return parts * (layout.DataShards + layout.ParityShards)
+}
+
+// putShards кодирует полосу в шарды данных и чётности и пишет каждый шард на свой узел.
+// Часть записана, если записалось не меньше erasureWriteQuorum шардов: недостающие восстанавливаются
+// из остальных при чтении и остаются в раскладке без узла. Узлы с неудачной записью ставятся в очередь
+// отложенных удалений, а если кворум не набран — туда же уходят и записанные шарды.
+//
+// Полоса кодируется в памяти вне бюджета UploadMemoryLimit: на каждую отправляемую часть
+// дополнительно уходит около part.Size*(k+m)/k байт.
+func (s *Files) putShards(ctx context.Context, fileID string, layout models.ErasureLayout, part *models.Part, buf *partBuffer, total int, nodes []string) error {
+	enc, err := s.codec(layout)
+	if err != nil {
+		return err
+	}
+
+	width := layout.DataShards + layout.ParityShards
+	size := max(part.Size, 1)
+	shardSize := (size + int64(layout.DataShards) - 1) / int64(layout.DataShards)
+	// Ёмкости хватает на все шарды: Split разместит в ней и шарды чётности без новых выделений.
+	// Ещё k байт запаса — Split обнуляет k байт за концом данных.
+	data := make([]byte, part.Size, shardSize*int64(width)+int64(layout.DataShards))
+	if _, err = io.ReadFull(buf.Reader(), data); err != nil {
+		return fmt.Errorf("read part %d: %w", part.Index, err)
+	}
+	if len(data) == 0 {
+		// Split не принимает пустые данные; Join всё равно обрежет результат до part.Size.
+		data = data[:1]
+	}
+	shards, err := enc.Split(data)
+	if err != nil {
+		return fmt.Errorf("split part %d: %w", part.Index, err)
+	}
+	if err = enc.Encode(shards); err != nil {
+		return fmt.Errorf("encode part %d: %w", part.Index, err)
+	}
+
+	part.ShardSize = int64(len(shards[0]))
+	part.Shards = make([]models.Shard, len(shards))
+	for j, shard := range shards {
+		sum := sha256.Sum256(shard)
+		part.Shards[j] = models.Shard{
+			Index:  shardIndex(layout, part.Index, j),
+			Sha256: hex.EncodeToString(sum[:]),
+		}
+		// Доступных узлов могло хватить только на кворум: лишние шарды остаются без узла.
+		if j < len(nodes) {
+			part.Shards[j].Storage = nodes[j]
+		}
+	}
+
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for j := range shards {
+		sh := part.Shards[j]
+		if sh.Storage == "" {
+			errs[j] = fmt.Errorf("shard %d of part %d: no storage", j, part.Index)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.StorageCli.PutPart(ctx, sh.Storage, storageclient.PutPartRequest{
+				FileID:     fileID,
+				Index:      sh.Index,
+				Reader:     bytes.NewReader(shards[j]),
+				Size:       part.ShardSize,
+				Sha256:     sh.Sha256,
+				TotalParts: storageTotal(&layout, total),
+			})
+			if err != nil {
+				errs[j] = fmt.Errorf("put shard %d of part %d to %s: %w", j, part.Index, sh.Storage, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		written int
+		failed  []error
+		cleanup []models.PendingDeletion
+	)
+	for j := range part.Shards {
+		sh := &part.Shards[j]
+		if errs[j] == nil {
+			written++
+			continue
+		}
+		failed = append(failed, errs[j])
+		if sh.Storage != "" {
+			cleanup = append(cleanup, models.PendingDeletion{
+				FileID:    fileID,
+				Index:     sh.Index,
+				Storage:   sh.Storage,
+				LastError: errs[j].Error(),
+			})
+			sh.Storage = ""
+		}
+	}
+
+	quorum := s.erasureWriteQuorum(layout)
+	if written < quorum {
+		for _, sh := range part.Shards {
+			if sh.Storage != "" {
+				cleanup = append(cleanup, models.PendingDeletion{FileID: fileID, Index: sh.Index, Storage: sh.Storage})
+			}
+		}
+		_ = s.queueDeletions(ctx, cleanup)
+		return fmt.Errorf("part %d: %d of %d shards written, quorum %d: %w",
+			part.Index, written, width, quorum, errors.Join(failed...))
+	}
+	_ = s.queueDeletions(ctx, cleanup)
+
+	return nil
+}
+
+// readStripe собирает полосу из шардов. Сначала читаются шарды данных; шарды чётности
+// подтягиваются только взамен недоступных или не прошедших проверку sha256.
+func (s *Files) readStripe(ctx context.Context, file models.File, part models.Part) ([]byte, error) {
+	layout := *file.Erasure
+	enc, err := s.codec(layout)
+	if err != nil {
+		return nil, err
+	}
+	if len(part.Shards) != layout.DataShards+layout.ParityShards {
+		return nil, fmt.Errorf("part %d of file %s: want %d shards, have %d",
+			part.Index, file.ID, layout.DataShards+layout.ParityShards, len(part.Shards))
+	}
+
+	shards := make([][]byte, len(part.Shards))
+	errs := make([]error, len(part.Shards))
+	fetch := func(idx []int) {
+		var wg sync.WaitGroup
+		for _, j := range idx {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				shards[j], errs[j] = s.readShard(ctx, file.ID, part, j)
+			}()
+		}
+		wg.Wait()
+	}
+
+	next := layout.DataShards
+	batch := make([]int, layout.DataShards)
+	for j := range batch {
+		batch[j] = j
+	}
+	for {
+		fetch(batch)
+
+		have := 0
+		for _, shard := range shards {
+			if shard != nil {
+				have++
+			}
+		}
+		if have >= layout.DataShards {
+			break
+		}
+		if next == len(shards) || ctx.Err() != nil {
+			return nil, fmt.Errorf("part %d of file %s: %d of %d shards readable: %w",
+				part.Index, file.ID, have, layout.DataShards, errors.Join(errs...))
+		}
+
+		need := min(layout.DataShards-have, len(shards)-next)
+		batch = batch[:0]
+		for ; need > 0; need-- {
+			batch = append(batch, next)
+			next++
+		}
+	}
+
+	if err = enc.ReconstructData(shards); err != nil {
+		return nil, fmt.Errorf("reconstruct part %d of file %s: %w", part.Index, file.ID, err)
+	}
+
+	var out bytes.Buffer
+	out.Grow(int(part.Size))
+	if err = enc.Join(&out, shards, int(part.Size)); err != nil {
+		return nil, fmt.Errorf("join part %d of file %s: %w", part.Index, file.ID, err)
+	}
+	data := out.Bytes()
+
+	if part.Sha256 != "" {
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != part.Sha256 {
+			return nil, fmt.Errorf("%w: file %s part %d after reconstruction", models.ErrCorrupted, file.ID, part.Index)
+		}
+	}
+
+	return data, nil
+}
+
+// readShard читает шард целиком и сверяет его размер и sha256.
+func (s *Files) readShard(ctx context.Context, fileID string, part models.Part, j int) ([]byte, error) {
+	sh := part.Shards[j]
+	if sh.Storage == "" {
+		return nil, fmt.Errorf("shard %d of part %d was not written", sh.Index, part.Index)
+	}
+	rc, err := s.StorageCli.GetPart(ctx, sh.Storage, fileID, sh.Index)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, part.ShardSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != part.ShardSize {
+		return nil, fmt.Errorf("shard %d on %s: size %d, want %d", sh.Index, sh.Storage, len(data), part.ShardSize)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != sh.Sha256 {
+		return nil, fmt.Errorf("%w: shard %d on %s", models.ErrCorrupted, sh.Index, sh.Storage)
+	}
+
+	return data, nil
+}
+
+// pumpStripe восстанавливает полосу из шардов и отдаёт в очередь prefetcher запрошенный отрезок.
+func (s *Files) pumpStripe(ctx context.Context, file models.File, p *prefetcher) error {
+	data, err := s.readStripe(ctx, file, p.seg.part)
+	if err != nil {
+		return err
+	}
+
+	out := data[p.seg.offset : p.seg.offset+p.seg.length]
+	for len(out) > 0 {
+		chunk := chunkPool.Get().(*[]byte)
+		n := copy(*chunk, out)
+		*chunk = (*chunk)[:n]
+		out = out[n:]
+
+		select {
+		case p.chunks <- chunk:
+		case <-ctx.Done():
+			putChunk(chunk)
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
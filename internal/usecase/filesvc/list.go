@@ -0,0 +1,12 @@
+package filesvc
+
+import (
+	"context"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// List возвращает страницу списка файлов.
+func (s *Files) List(ctx context.Context, filter models.ListFilter) (models.FileList, error) {
+	return s.MetaStorage.List(ctx, filter.Normalize())
+}
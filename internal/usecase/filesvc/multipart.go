@@ -0,0 +1,330 @@
+package filesvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const (
+	// DefaultMaxUploadPartSize — предельный размер одной части составной загрузки.
+	DefaultMaxUploadPartSize int64 = 5 << 30
+	// DefaultUploadTTL — срок жизни сессии; вдвое меньше TTL GC стораджей по умолчанию (24 часа), который
+	// удаляет каталоги незавершённых загрузок: сессия должна истечь раньше, чем GC доберётся до её частей.
+	DefaultUploadTTL = 12 * time.Hour
+	// expiredUploadsBatch ограничивает число сессий, удаляемых за один проход ExpireUploads.
+	expiredUploadsBatch = 100
+)
+
+// CreateUpload открывает сессию составной загрузки. Идентификатор сессии станет идентификатором файла.
+func (s *Files) CreateUpload(ctx context.Context, spec models.UploadSpec) (models.Upload, error) {
+	if s.Uploads == nil {
+		return models.Upload{}, fmt.Errorf("multipart uploads are not supported by the meta store")
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	upload := models.Upload{
+		ID:        uuid.NewString(),
+		Name:      strings.TrimSpace(spec.Name),
+		Length:    spec.Length,
+		Metadata:  spec.Metadata,
+		Tus:       spec.Tus,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.uploadTTL()),
+		Erasure:   s.erasureLayout(),
+		Parts:     make(map[int]models.Part),
+	}
+	if spec.Bucket != "" || spec.Key != "" {
+		// Бакет проверяется сразу, чтобы не принимать части, которые некуда будет сохранить.
+		if err := s.checkObject(ctx, spec.Bucket, spec.Key); err != nil {
+			return models.Upload{}, err
+		}
+		upload.Name, upload.Bucket, upload.Key = spec.Key, spec.Bucket, spec.Key
+	}
+	if err := s.Uploads.CreateUpload(ctx, upload); err != nil {
+		return models.Upload{}, err
+	}
+
+	return upload, nil
+}
+
+// GetUpload возвращает сессию и уже принятые части. Сессия с истёкшим сроком считается удалённой,
+// даже если ExpireUploads до неё ещё не добрался.
+func (s *Files) GetUpload(ctx context.Context, uploadID string) (models.Upload, error) {
+	if s.Uploads == nil {
+		return models.Upload{}, models.ErrUploadNotFound
+	}
+	upload, err := s.Uploads.GetUpload(ctx, uploadID)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	if upload.Expired(time.Now()) {
+		return models.Upload{}, models.ErrUploadNotFound
+	}
+
+	return upload, nil
+}
+
+// UploadPart принимает часть number (1..models.MaxUploadParts) и пишет её на стораджи.
+// Части можно присылать в любом порядке и повторять: новая версия заменяет прежнюю,
+// а её копии, оставшиеся на других узлах, уходят в очередь удаления.
+// Непустой checksum — ожидаемый hex sha256 тела части.
+func (s *Files) UploadPart(ctx context.Context, uploadID string, number int, r io.Reader, checksum string) (models.Part, error) {
+	if number < 1 || number > models.MaxUploadParts {
+		return models.Part{}, fmt.Errorf("%w: part number must be in 1..%d", models.ErrInvalidPart, models.MaxUploadParts)
+	}
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return models.Part{}, err
+	}
+
+	limit := s.maxUploadPartSize(upload.Erasure)
+	buf, release := s.newPartBuffer(limit)
+	defer func() {
+		_ = buf.Close()
+		release()
+	}()
+
+	n, err := buf.fill(r, limit+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return models.Part{}, fmt.Errorf("read part %d: %w", number, err)
+	}
+	if n > limit {
+		return models.Part{}, fmt.Errorf("%w: limit is %d bytes", models.ErrTooLarge, limit)
+	}
+
+	part := models.Part{
+		Index:  number - 1,
+		Size:   buf.Size(),
+		Sha256: buf.Sha256(),
+	}
+	if checksum != "" && !strings.EqualFold(checksum, part.Sha256) {
+		return models.Part{}, fmt.Errorf("%w: %w", models.ErrInvalidPart, models.ErrChecksum)
+	}
+
+	width, minimum := s.placement(upload.Erasure)
+	allocated, err := s.Router.AllocateReplicas(ctx, 1, width, minimum)
+	if err != nil {
+		return models.Part{}, err
+	}
+
+	// Число частей ещё неизвестно: на стораджи уходит total 0, итог фиксирует CompleteUpload.
+	if upload.Erasure != nil {
+		err = s.putShards(ctx, upload.ID, *upload.Erasure, &part, buf, 0, allocated[0])
+	} else {
+		var stored []string
+		if stored, err = s.putReplicas(ctx, upload.ID, part, buf, 0, allocated[0]); err == nil {
+			part.Storage = stored[0]
+			part.Storages = stored
+		}
+	}
+	if err != nil {
+		return models.Part{}, err
+	}
+
+	prev, replaced, err := s.Uploads.PutUploadPart(ctx, upload.ID, part)
+	if err != nil {
+		// Сессию успели отменить: только что записанные копии никому не нужны. Если же её завершили,
+		// те же объекты могут принадлежать готовому файлу — их не трогаем.
+		if _, ferr := s.MetaStorage.Get(ctx, upload.ID); errors.Is(ferr, models.ErrNotFound) {
+			_ = s.queueDeletions(ctx, pendingFor(upload.ID, part.Placements()))
+		}
+		return models.Part{}, err
+	}
+	if replaced {
+		_ = s.queueDeletions(ctx, pendingFor(upload.ID, stalePlacements(prev, part)))
+	}
+	// Прошлые попытки могли поставить эти же объекты в очередь удаления — снимаем их оттуда.
+	if s.Deletions != nil {
+		for _, d := range pendingFor(upload.ID, part.Placements()) {
+			_ = s.Deletions.RemovePendingDeletion(ctx, d)
+		}
+	}
+
+	return part, nil
+}
+
+// CompleteUpload собирает файл из частей 1..N сессии. Пропуски в нумерации не допускаются.
+// Сессия с адресом объекта заменяет объект с тем же ключом.
+func (s *Files) CompleteUpload(ctx context.Context, uploadID string) (models.UploadResult, error) {
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return models.UploadResult{}, err
+	}
+	if len(upload.Parts) == 0 {
+		return models.UploadResult{}, fmt.Errorf("%w: no parts uploaded", models.ErrInvalidPart)
+	}
+
+	total := 0
+	for idx := range upload.Parts {
+		total = max(total, idx+1)
+	}
+
+	file := models.File{
+		ID:         upload.ID,
+		Name:       upload.Name,
+		TotalParts: total,
+		Parts:      upload.Parts,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+		Erasure:    upload.Erasure,
+		Bucket:     upload.Bucket,
+		Key:        upload.Key,
+		Tus:        upload.Tus,
+	}
+	for idx := 0; idx < total; idx++ {
+		part, ok := upload.Parts[idx]
+		if !ok {
+			return models.UploadResult{}, fmt.Errorf("%w: part %d is missing", models.ErrInvalidPart, idx+1)
+		}
+		file.Size += part.Size
+	}
+
+	if upload.Length > 0 && file.Size != upload.Length {
+		return models.UploadResult{}, fmt.Errorf("%w: received %d of %d bytes", models.ErrInvalidPart, file.Size, upload.Length)
+	}
+
+	// При сбое части остаются за сессией: их удалят AbortUpload или ExpireUploads, даже завершённые.
+	if err = s.finalizeStorages(ctx, file); err != nil {
+		return models.UploadResult{}, err
+	}
+	if err = s.commit(ctx, file); err != nil {
+		return models.UploadResult{}, err
+	}
+	if err = s.Uploads.DeleteUpload(ctx, upload.ID); err != nil && !errors.Is(err, models.ErrUploadNotFound) {
+		return models.UploadResult{}, err
+	}
+
+	return models.UploadResult{FileID: file.ID, Size: file.Size, Parts: file.TotalParts}, nil
+}
+
+// AbortUpload отменяет сессию и удаляет принятые части со стораджей.
+func (s *Files) AbortUpload(ctx context.Context, uploadID string) error {
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+
+	return s.dropUpload(ctx, upload)
+}
+
+// ExpireUploads удаляет сессии с истёкшим сроком вместе с принятыми частями и возвращает их число.
+func (s *Files) ExpireUploads(ctx context.Context) (int, error) {
+	if s.Uploads == nil {
+		return 0, nil
+	}
+
+	uploads, err := s.Uploads.ListExpiredUploads(ctx, time.Now(), expiredUploadsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for _, upload := range uploads {
+		if ctx.Err() != nil {
+			break
+		}
+		err = s.dropUpload(ctx, upload)
+		if errors.Is(err, models.ErrUploadNotFound) {
+			continue
+		}
+		if err != nil {
+			return done, err
+		}
+		done++
+	}
+
+	return done, ctx.Err()
+}
+
+// StartUploadExpiry периодически удаляет брошенные сессии составной загрузки.
+func StartUploadExpiry(svc Service, every time.Duration) func() {
+	if svc == nil || every <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(every)
+	var once sync.Once
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = svc.ExpireUploads(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(cancel)
+	}
+}
+
+// dropUpload удаляет сессию и её части со стораджей; недоступные узлы дочищает очередь удалений.
+func (s *Files) dropUpload(ctx context.Context, upload models.Upload) error {
+	if err := s.Uploads.DeleteUpload(ctx, upload.ID); err != nil {
+		return err
+	}
+
+	var pending []models.PendingDeletion
+	for _, part := range upload.Parts {
+		pending = append(pending, s.removePlacements(ctx, upload.ID, part.Placements())...)
+	}
+
+	return s.queueDeletions(ctx, pending)
+}
+
+func (s *Files) uploadTTL() time.Duration {
+	if s.UploadTTL <= 0 {
+		return DefaultUploadTTL
+	}
+	return s.UploadTTL
+}
+
+func (s *Files) maxUploadPartSize(layout *models.ErasureLayout) int64 {
+	limit := s.MaxUploadPartSize
+	if limit <= 0 {
+		limit = DefaultMaxUploadPartSize
+	}
+	// Закодированная часть — одна полоса, которая целиком держится в памяти.
+	if layout != nil {
+		limit = min(limit, s.erasureStripeSize())
+	}
+
+	return limit
+}
+
+// stalePlacements возвращает объекты прежней версии части, которые не перезаписаны новой.
+func stalePlacements(prev, cur models.Part) []models.Placement {
+	kept := make(map[models.Placement]struct{})
+	for _, pl := range cur.Placements() {
+		kept[pl] = struct{}{}
+	}
+
+	var out []models.Placement
+	for _, pl := range prev.Placements() {
+		if _, ok := kept[pl]; !ok {
+			out = append(out, pl)
+		}
+	}
+
+	return out
+}
+
+func pendingFor(fileID string, placements []models.Placement) []models.PendingDeletion {
+	out := make([]models.PendingDeletion, 0, len(placements))
+	for _, pl := range placements {
+		out = append(out, models.PendingDeletion{FileID: fileID, Index: pl.Index, Storage: pl.Storage})
+	}
+
+	return out
+}
@@ -0,0 +1,242 @@
+package filesvc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+const (
+	// DefaultDownloadPrefetch — сколько частей открываются со стораджей одновременно при чтении.
+	DefaultDownloadPrefetch = 3
+	// DefaultDownloadBufferBytes — объём упреждающего чтения на одну часть.
+	DefaultDownloadBufferBytes int64 = 4 << 20
+
+	prefetchChunkSize = 256 << 10
+)
+
+var chunkPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, prefetchChunkSize)
+		return &b
+	},
+}
+
+// prefetcher читает один сегмент в фоне в ограниченную очередь кусков.
+type prefetcher struct {
+	seg    segment
+	chunks chan *[]byte
+	// err выставляется до закрытия chunks и читается только после него.
+	err error
+}
+
+// streamPrefetched отдаёт сегменты в w строго по порядку, держа открытыми до DownloadPrefetch
+// следующих частей. Каждая часть читается в фоне не дальше чем на DownloadBufferBytes вперёд;
+// при ошибке или отключении клиента все фоновые чтения отменяются.
+func (s *Files) streamPrefetched(ctx context.Context, file models.File, segments []segment, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	depth := s.downloadPrefetch()
+	queueLen := max(1, int(s.downloadBufferBytes()/prefetchChunkSize))
+
+	window := make([]*prefetcher, 0, depth)
+	next := 0
+	fill := func() {
+		for len(window) < depth && next < len(segments) {
+			p := &prefetcher{
+				seg:    segments[next],
+				chunks: make(chan *[]byte, queueLen),
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.pump(ctx, file, p)
+			}()
+			window = append(window, p)
+			next++
+		}
+	}
+
+	for fill(); len(window) > 0; fill() {
+		p := window[0]
+		window = window[1:]
+
+		if err := drain(p, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// drain копирует куски сегмента в w и возвращает буферы в пул.
+func drain(p *prefetcher, w io.Writer) error {
+	var writeErr error
+	for chunk := range p.chunks {
+		if writeErr == nil {
+			_, writeErr = w.Write(*chunk)
+		}
+		putChunk(chunk)
+		if writeErr != nil {
+			// Клиент отвалился: выходим, отмена контекста остановит фоновое чтение.
+			return writeErr
+		}
+	}
+
+	return p.err
+}
+
+// pump открывает сегмент на сторадже и перекладывает данные в очередь prefetcher.
+// Пока клиенту не ушло ни байта сегмента, ошибка чтения или контрольной суммы
+// переключает чтение на следующую реплику части; закодированные части собираются из шардов.
+func (s *Files) pump(ctx context.Context, file models.File, p *prefetcher) {
+	defer close(p.chunks)
+
+	if file.Erasure != nil {
+		p.err = s.pumpStripe(ctx, file, p)
+		return
+	}
+
+	locations := p.seg.part.Locations()
+	if len(locations) == 0 {
+		p.err = fmt.Errorf("part %d of file %s has no storages", p.seg.part.Index, file.ID)
+		return
+	}
+
+	var errs []error
+	for _, storage := range locations {
+		sent, err := s.pumpFrom(ctx, file, p, storage)
+		if err == nil {
+			p.err = nil
+			return
+		}
+		errs = append(errs, err)
+		if sent || ctx.Err() != nil {
+			break
+		}
+	}
+	p.err = errors.Join(errs...)
+}
+
+// pumpFrom читает сегмент с одного стораджа. Для целых частей считается sha256, а последний кусок
+// удерживается до сверки с models.Part.Sha256: повреждённая часть никогда не доходит до клиента целиком,
+// а часть, уместившаяся в один кусок, не уходит клиенту вовсе. Сегменты, режущие часть, читаются
+// диапазоном и не сверяются. sent сообщает, отдан ли уже хоть один кусок.
+func (s *Files) pumpFrom(ctx context.Context, file models.File, p *prefetcher, storage string) (sent bool, err error) {
+	reader, err := s.openSegment(ctx, file, p.seg, storage)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	var (
+		hasher  hash.Hash
+		held    *[]byte
+		total   int64
+		release = func(chunk *[]byte) error {
+			select {
+			case p.chunks <- chunk:
+				sent = true
+				return nil
+			case <-ctx.Done():
+				putChunk(chunk)
+				return ctx.Err()
+			}
+		}
+	)
+	defer func() {
+		if held != nil {
+			putChunk(held)
+		}
+	}()
+	verify := p.seg.whole() && p.seg.part.Sha256 != ""
+	if verify {
+		hasher = sha256.New()
+	}
+
+	for {
+		chunk := chunkPool.Get().(*[]byte)
+		n, readErr := io.ReadFull(reader, *chunk)
+		if n == 0 {
+			putChunk(chunk)
+		} else {
+			*chunk = (*chunk)[:n]
+			total += int64(n)
+			if hasher != nil {
+				hasher.Write(*chunk)
+			}
+			if held != nil {
+				prev := held
+				held = nil
+				if err = release(prev); err != nil {
+					putChunk(chunk)
+					return sent, err
+				}
+			}
+			held = chunk
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return sent, readErr
+		}
+	}
+
+	if total != p.seg.length {
+		return sent, fmt.Errorf("part %d: short read: want %d, got %d", p.seg.part.Index, p.seg.length, total)
+	}
+	if verify {
+		if got := hex.EncodeToString(hasher.Sum(nil)); got != p.seg.part.Sha256 {
+			return sent, fmt.Errorf("%w: file %s part %d on %s", models.ErrCorrupted, file.ID, p.seg.part.Index, storage)
+		}
+	}
+
+	if held != nil {
+		chunk := held
+		held = nil
+		return sent, release(chunk)
+	}
+
+	return sent, nil
+}
+
+func (s *Files) openSegment(ctx context.Context, file models.File, seg segment, storage string) (io.ReadCloser, error) {
+	if seg.whole() {
+		return s.StorageCli.GetPart(ctx, storage, file.ID, seg.part.Index)
+	}
+	return s.StorageCli.GetPartRange(ctx, storage, file.ID, seg.part.Index, seg.offset, seg.length)
+}
+
+// putChunk возвращает кусок в пул, восстанавливая полную длину.
+func putChunk(chunk *[]byte) {
+	*chunk = (*chunk)[:cap(*chunk)]
+	chunkPool.Put(chunk)
+}
+
+func (s *Files) downloadPrefetch() int {
+	if s.DownloadPrefetch <= 0 {
+		return DefaultDownloadPrefetch
+	}
+	return s.DownloadPrefetch
+}
+
+func (s *Files) downloadBufferBytes() int64 {
+	if s.DownloadBufferBytes <= 0 {
+		return DefaultDownloadBufferBytes
+	}
+	return s.DownloadBufferBytes
+}
@@ -3,7 +3,6 @@ package filesvc
 import (
 	"context"
 	"fmt"
-	"strings"
 	"sync"
 
 	"github.com/sir_venger/s3_lite/internal/models"
@@ -16,8 +15,10 @@ type StorageAdapter interface {
 
 // Router отвечает за выбор стораджей для записи файлов.
 type Router struct {
-	mu             sync.Mutex
-	configured     []string
+	mu         sync.Mutex
+	configured []string
+	// draining — узлы кластера, которые не получают новых частей.
+	draining       map[string]struct{}
 	next           int
 	StorageAdapter StorageAdapter
 }
@@ -27,64 +28,82 @@ func NewRouter(adapter StorageAdapter) *Router {
 	return &Router{StorageAdapter: adapter}
 }
 
-// Set заменяет список стораджей на новый.
+// Set заменяет список стораджей на новый; все узлы принимают запись.
 func (r *Router) Set(storages []string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	r.configured = append([]string{}, storages...)
+	r.draining = nil
 	r.next = 0
 }
 
-// Add добавляет новые стораджи, игнорируя дубликаты и пустые значения.
-func (r *Router) Add(storages ...string) {
-	if len(storages) == 0 {
-		return
-	}
+// SetNodes заменяет состав кластера. Узлы в состоянии draining остаются известны,
+// но в выборку для записи не попадают.
+func (r *Router) SetNodes(nodes []models.StorageNode) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	known := make(map[string]struct{}, len(r.configured))
-	for _, s := range r.configured {
-		known[s] = struct{}{}
-	}
-
-	for _, storage := range storages {
-		storage = strings.TrimSpace(storage)
-		if storage == "" {
-			continue
-		}
-
-		if _, exists := known[storage]; exists {
-			continue
+	r.configured = r.configured[:0:0]
+	r.draining = make(map[string]struct{})
+	for _, node := range nodes {
+		r.configured = append(r.configured, node.URL)
+		if node.State == models.NodeDraining {
+			r.draining[node.URL] = struct{}{}
 		}
-
-		r.configured = append(r.configured, storage)
-		known[storage] = struct{}{}
 	}
 }
 
 // Allocate возвращает список стораджей длиной count
 func (r *Router) Allocate(ctx context.Context, count int) ([]string, error) {
+	replicas, err := r.AllocateReplicas(ctx, count, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, count)
+	for i, nodes := range replicas {
+		result[i] = nodes[0]
+	}
+
+	return result, nil
+}
+
+// AllocateReplicas возвращает для каждой из count частей набор различных стораджей.
+// Если доступных узлов меньше factor, набор урезается до их числа, но не ниже minimum.
+func (r *Router) AllocateReplicas(ctx context.Context, count, factor, minimum int) ([][]string, error) {
 	if count <= 0 {
 		return nil, fmt.Errorf("count must be positive")
 	}
+	if factor <= 0 {
+		factor = 1
+	}
 
 	r.mu.Lock()
 	if len(r.configured) == 0 {
 		r.mu.Unlock()
 		return nil, fmt.Errorf("no storages configured")
 	}
-	snapshot := append([]string{}, r.configured...)
+	snapshot := make([]string, 0, len(r.configured))
+	for _, storage := range r.configured {
+		if _, ok := r.draining[storage]; !ok {
+			snapshot = append(snapshot, storage)
+		}
+	}
 	r.mu.Unlock()
+	if len(snapshot) == 0 {
+		return nil, fmt.Errorf("%w: all storages are draining", models.ErrNoStorage)
+	}
 
+	// Узлы down, degraded (в том числе без свободного места) не получают частей, даже если других нет.
 	available := r.StorageAdapter.Available(ctx, snapshot)
 	if len(available) == 0 {
-		available = snapshot
+		return nil, fmt.Errorf("%w: none of %d storages is up", models.ErrNoStorage, len(snapshot))
 	}
 
-	if len(snapshot) == 0 && len(available) == 0 {
-		return nil, models.ErrNoStorage
+	width := min(factor, len(available))
+	if width < minimum {
+		return nil, fmt.Errorf("%w: need %d distinct storages, %d available", models.ErrNoStorage, minimum, len(available))
 	}
 
 	r.mu.Lock()
@@ -92,9 +111,15 @@ func (r *Router) Allocate(ctx context.Context, count int) ([]string, error) {
 	r.next = (start + count) % len(available)
 	r.mu.Unlock()
 
-	result := make([]string, count)
+	// Реплики части i — соседние узлы по кругу начиная с start+i: они различны,
+	// а первичные копии соседних частей по-прежнему разъезжаются по разным узлам.
+	result := make([][]string, count)
 	for i := 0; i < count; i++ {
-		result[i] = available[(start+i)%len(available)]
+		nodes := make([]string, width)
+		for j := range nodes {
+			nodes[j] = available[(start+i+j)%len(available)]
+		}
+		result[i] = nodes
 	}
 
 	return result, nil
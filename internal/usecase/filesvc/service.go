@@ -3,9 +3,12 @@ package filesvc
 import (
 	"context"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/sir_venger/s3_lite/internal/models"
 	"github.com/sir_venger/s3_lite/pkg/storageclient"
+	"golang.org/x/sync/semaphore"
 )
 
 type (
@@ -13,38 +16,144 @@ type (
 	MetaStorage interface {
 		Get(ctx context.Context, id string) (models.File, error)
 		Save(ctx context.Context, file models.File) error
+		Delete(ctx context.Context, id string) error
+		List(ctx context.Context, filter models.ListFilter) (models.FileList, error)
+	}
+
+	// PendingDeletions очередь частей, которые не удалось удалить со стораджей сразу
+	PendingDeletions interface {
+		AddPendingDeletions(ctx context.Context, items []models.PendingDeletion) error
+		ListPendingDeletions(ctx context.Context, limit int) ([]models.PendingDeletion, error)
+		RemovePendingDeletion(ctx context.Context, d models.PendingDeletion) error
+	}
+
+	// UploadSessions хранит состояние сессий составной загрузки.
+	UploadSessions interface {
+		CreateUpload(ctx context.Context, upload models.Upload) error
+		GetUpload(ctx context.Context, id string) (models.Upload, error)
+		PutUploadPart(ctx context.Context, id string, part models.Part) (prev models.Part, replaced bool, err error)
+		DeleteUpload(ctx context.Context, id string) error
+		ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]models.Upload, error)
+		LockUpload(ctx context.Context, id, owner string, ttl time.Duration) error
+		UnlockUpload(ctx context.Context, id, owner string) error
+	}
+
+	// Buckets хранит бакеты и индекс ключей их объектов.
+	Buckets interface {
+		CreateBucket(ctx context.Context, name string) (models.Bucket, error)
+		GetBucket(ctx context.Context, name string) (models.Bucket, error)
+		ListBuckets(ctx context.Context) ([]models.Bucket, error)
+		DeleteBucket(ctx context.Context, name string) error
+		GetObject(ctx context.Context, bucket, key string) (models.File, error)
+		PutObject(ctx context.Context, file models.File) (prev models.File, replaced bool, err error)
+	}
+
+	// StorageNodes хранит состав кластера стораджей, общий для всех реплик REST.
+	StorageNodes interface {
+		ListStorageNodes(ctx context.Context) ([]models.StorageNode, error)
+		PutStorageNode(ctx context.Context, node models.StorageNode) (models.StorageNode, error)
+		DeleteStorageNode(ctx context.Context, url string) error
+	}
+
+	// APIKeys хранит ключи доступа к REST.
+	APIKeys interface {
+		CreateAPIKey(ctx context.Context, key models.APIKey) error
+		GetAPIKey(ctx context.Context, id string) (models.APIKey, error)
+		ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+		DeleteAPIKey(ctx context.Context, id string) error
 	}
 
 	// Service объединяет операции по загрузке и выдаче файлов.
 	Service interface {
 		UploadWhole(ctx context.Context, r io.Reader, size int64, name string) (models.UploadResult, error)
+		Stat(ctx context.Context, fileID string) (models.File, error)
 		Stream(ctx context.Context, fileID string, w io.Writer) error
-		AddStorages(storages ...string)
+		StreamRange(ctx context.Context, file models.File, offset, length int64, w io.Writer) error
+		Delete(ctx context.Context, fileID string) error
+		List(ctx context.Context, filter models.ListFilter) (models.FileList, error)
+		RetryPendingDeletions(ctx context.Context) (int, error)
+
+		ListStorages(ctx context.Context) ([]models.StorageNode, error)
+		PutStorages(ctx context.Context, state models.NodeState, urls ...string) ([]models.StorageNode, error)
+		RemoveStorage(ctx context.Context, url string) error
+		SyncStorages(ctx context.Context) ([]models.StorageNode, error)
+
+		CreateUpload(ctx context.Context, spec models.UploadSpec) (models.Upload, error)
+		GetUpload(ctx context.Context, uploadID string) (models.Upload, error)
+		UploadPart(ctx context.Context, uploadID string, number int, r io.Reader, checksum string) (models.Part, error)
+		CompleteUpload(ctx context.Context, uploadID string) (models.UploadResult, error)
+		AbortUpload(ctx context.Context, uploadID string) error
+		AppendUpload(ctx context.Context, uploadID string, offset int64, r io.Reader, checksum string) (int64, error)
+		ExpireUploads(ctx context.Context) (int, error)
+
+		CreateBucket(ctx context.Context, name string) (models.Bucket, error)
+		GetBucket(ctx context.Context, name string) (models.Bucket, error)
+		ListBuckets(ctx context.Context) ([]models.Bucket, error)
+		DeleteBucket(ctx context.Context, name string) error
+		PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64) (models.UploadResult, error)
+		StatObject(ctx context.Context, bucket, key string) (models.File, error)
+		DeleteObject(ctx context.Context, bucket, key string) error
+
+		CreateAPIKey(ctx context.Context, name string, perms []models.Permission) (models.APIKey, string, error)
+		ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+		DeleteAPIKey(ctx context.Context, id string) error
+		AuthenticateAPIKey(ctx context.Context, token string) (models.APIKey, error)
 	}
 )
 
 type Deps struct {
 	MetaStorage MetaStorage
+	Deletions   PendingDeletions
+	Uploads     UploadSessions
+	Buckets     Buckets
+	Membership  StorageNodes
+	Keys        APIKeys
 	Router      *Router
 	StorageCli  storageclient.Client
 	Parts       int
+	// StreamPartSize — размер части для загрузок без Content-Length (0 — DefaultStreamPartSize).
+	StreamPartSize int64
+	// MaxUploadPartSize — предельный размер части составной загрузки (0 — DefaultMaxUploadPartSize).
+	MaxUploadPartSize int64
+	// UploadTTL — срок жизни сессии составной загрузки от её создания (0 — DefaultUploadTTL).
+	UploadTTL time.Duration
+	// ReplicationFactor — на сколько различных узлов пишется каждая часть (0 — 1).
+	ReplicationFactor int
+	// WriteQuorum — минимум успешно записанных копий части (0 — большинство от ReplicationFactor).
+	WriteQuorum int
+	// ErasureDataShards и ErasureParityShards включают erasure coding вместо репликации (оба > 0).
+	ErasureDataShards   int
+	ErasureParityShards int
+	// ErasureWriteQuorum — минимум записанных шардов полосы (0 — k+1; не меньше k и не больше k+m).
+	ErasureWriteQuorum int
+	// ErasureStripeSize — объём данных в одной полосе при erasure coding (0 — DefaultErasureStripeSize).
+	ErasureStripeSize int64
+	// SpoolDir — каталог для временных файлов буферизации частей (пусто — os.TempDir()).
+	SpoolDir string
+	// UploadConcurrency — число частей одной загрузки, отправляемых параллельно (0 — DefaultUploadConcurrency).
+	UploadConcurrency int
+	// UploadMemoryLimit — общий бюджет памяти под буферы частей всех загрузок (0 — DefaultUploadMemoryLimit).
+	UploadMemoryLimit int64
+	// DownloadPrefetch — число частей, открываемых со стораджей заранее при чтении (0 — DefaultDownloadPrefetch).
+	DownloadPrefetch int
+	// DownloadBufferBytes — объём упреждающего чтения на одну часть (0 — DefaultDownloadBufferBytes).
+	DownloadBufferBytes int64
 }
 
 type Files struct {
 	Deps
+
+	memBudget *semaphore.Weighted
+	// codecs кэширует кодировщики Reed–Solomon по схеме models.ErasureLayout.
+	codecs sync.Map
 }
 
 // New конструирует сервис загрузки с заданными зависимостями.
 func New(deps Deps) *Files {
-	return &Files{Deps: deps}
+	s := &Files{Deps: deps}
+	s.memBudget = semaphore.NewWeighted(s.uploadMemoryLimit())
+
+	return s
 }
 
 var _ Service = (*Files)(nil)
-
-// AddStorages добавляет новые стораджи в маршрутизатор без удаления существующих.
-func (s *Files) AddStorages(storages ...string) {
-	if s.Router == nil || len(storages) == 0 {
-		return
-	}
-	s.Router.Add(storages...)
-}
@@ -0,0 +1,143 @@
+package filesvc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+var errNoMembership = fmt.Errorf("storage membership is not supported by the meta store")
+
+// InitStorages готовит состав кластера при старте: пустой состав заполняется узлами seed
+// из конфига, после чего маршрутизатор получает сохранённый состав. Дальше состав меняется
+// только через PutStorages и RemoveStorage.
+func (s *Files) InitStorages(ctx context.Context, seed []string) error {
+	if s.Membership == nil {
+		s.Router.Set(seed)
+		return nil
+	}
+
+	nodes, err := s.Membership.ListStorageNodes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 && len(seed) > 0 {
+		if _, err = s.PutStorages(ctx, models.NodeActive, seed...); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.SyncStorages(ctx)
+	return err
+}
+
+// ListStorages возвращает состав кластера.
+func (s *Files) ListStorages(ctx context.Context) ([]models.StorageNode, error) {
+	if s.Membership == nil {
+		return nil, errNoMembership
+	}
+	return s.Membership.ListStorageNodes(ctx)
+}
+
+// PutStorages добавляет узлы в кластер или переводит уже известные в состояние state.
+func (s *Files) PutStorages(ctx context.Context, state models.NodeState, urls ...string) ([]models.StorageNode, error) {
+	if s.Membership == nil {
+		return nil, errNoMembership
+	}
+	if !models.ValidNodeState(state) {
+		return nil, fmt.Errorf("%w: state %q", models.ErrInvalidStorage, state)
+	}
+
+	normalized := make([]string, 0, len(urls))
+	for _, u := range urls {
+		u = normalizeStorageURL(u)
+		if !models.ValidStorageURL(u) {
+			return nil, fmt.Errorf("%w: url %q", models.ErrInvalidStorage, u)
+		}
+		normalized = append(normalized, u)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	out := make([]models.StorageNode, 0, len(normalized))
+	for i, u := range normalized {
+		// Узлы расходятся на микросекунду, чтобы состав (порядок по времени добавления)
+		// и round-robin маршрутизатора сохраняли порядок из запроса.
+		at := now.Add(time.Duration(i) * time.Microsecond)
+		node, err := s.Membership.PutStorageNode(ctx, models.StorageNode{URL: u, State: state, UpdatedAt: at})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+
+	if _, err := s.SyncStorages(ctx); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RemoveStorage убирает узел из кластера. Записанные на нём части остаются в раскладке файлов
+// и читаются, пока узел доступен: перед удалением узел стоит перевести в draining.
+func (s *Files) RemoveStorage(ctx context.Context, url string) error {
+	if s.Membership == nil {
+		return errNoMembership
+	}
+	if err := s.Membership.DeleteStorageNode(ctx, normalizeStorageURL(url)); err != nil {
+		return err
+	}
+
+	_, err := s.SyncStorages(ctx)
+	return err
+}
+
+// SyncStorages перечитывает состав кластера из метаданных и применяет его к маршрутизатору:
+// так до каждой реплики REST доходят изменения, сделанные через другие.
+func (s *Files) SyncStorages(ctx context.Context) ([]models.StorageNode, error) {
+	if s.Membership == nil {
+		return nil, errNoMembership
+	}
+
+	nodes, err := s.Membership.ListStorageNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.Router.SetNodes(nodes)
+
+	return nodes, nil
+}
+
+// StartStorageSync периодически перечитывает состав кластера; onSync получает актуальный состав.
+func StartStorageSync(svc Service, every time.Duration, onSync func([]models.StorageNode)) func() {
+	if svc == nil || every <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(every)
+	var once sync.Once
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if nodes, err := svc.SyncStorages(ctx); err == nil && onSync != nil {
+					onSync(nodes)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(cancel)
+	}
+}
+
+func normalizeStorageURL(u string) string {
+	return strings.TrimRight(strings.TrimSpace(u), "/")
+}
@@ -2,11 +2,17 @@ package filesvc
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/sir_venger/s3_lite/internal/models"
 )
 
+// Stat возвращает метаданные файла без чтения данных.
+func (s *Files) Stat(ctx context.Context, fileID string) (models.File, error) {
+	return s.MetaStorage.Get(ctx, fileID)
+}
+
 // Stream читает данные по частям из стораджей и транслирует клиенту.
 func (s *Files) Stream(ctx context.Context, fileID string, w io.Writer) error {
 	file, err := s.MetaStorage.Get(ctx, fileID)
@@ -14,24 +20,66 @@ func (s *Files) Stream(ctx context.Context, fileID string, w io.Writer) error {
 		return err
 	}
 
-	for idx := 0; idx < file.TotalParts; idx++ {
+	return s.StreamRange(ctx, file, 0, file.Size, w)
+}
+
+// StreamRange транслирует клиенту length байт файла, начиная с offset.
+// Запрашиваются только части, пересекающиеся с диапазоном, а крайние из них — частично.
+func (s *Files) StreamRange(ctx context.Context, file models.File, offset, length int64, w io.Writer) error {
+	if offset < 0 || length < 0 || offset+length > file.Size {
+		return fmt.Errorf("range out of bounds: offset %d, length %d, size %d", offset, length, file.Size)
+	}
+
+	segments, err := planSegments(file, offset, length)
+	if err != nil {
+		return err
+	}
+
+	return s.streamPrefetched(ctx, file, segments, w)
+}
+
+// segment описывает кусок одной части, который нужно отдать клиенту.
+type segment struct {
+	part   models.Part
+	offset int64
+	length int64
+}
+
+// whole сообщает, покрывает ли сегмент часть целиком.
+func (seg segment) whole() bool {
+	return seg.offset == 0 && seg.length == seg.part.Size
+}
+
+// planSegments раскладывает байтовый диапазон файла на сегменты частей.
+// Отсутствие любой из нужных частей обнаруживается до начала передачи.
+func planSegments(file models.File, offset, length int64) ([]segment, error) {
+	var (
+		out   []segment
+		start int64
+		end   = offset + length
+	)
+	for idx := 0; idx < file.TotalParts && start < end; idx++ {
 		part, ok := file.Parts[idx]
 		if !ok {
-			return models.ErrIncomplete
-		}
-
-		reader, err := s.StorageCli.GetPart(ctx, part.Storage, file.ID, idx)
-		if err != nil {
-			return err
+			return nil, models.ErrIncomplete
 		}
 
-		if _, copyErr := io.Copy(w, reader); copyErr != nil {
-			reader.Close()
-			return copyErr
+		partEnd := start + part.Size
+		if partEnd > offset && part.Size > 0 {
+			from := max(offset, start)
+			to := min(end, partEnd)
+			out = append(out, segment{
+				part:   part,
+				offset: from - start,
+				length: to - from,
+			})
 		}
+		start = partEnd
+	}
 
-		reader.Close()
+	if start < end {
+		return nil, models.ErrIncomplete
 	}
 
-	return nil
+	return out, nil
 }
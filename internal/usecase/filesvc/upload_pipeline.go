@@ -0,0 +1,280 @@
+package filesvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sir_venger/s3_lite/internal/models"
+	"github.com/sir_venger/s3_lite/pkg/storageclient"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultUploadConcurrency — сколько частей одной загрузки отправляются на стораджи одновременно.
+	DefaultUploadConcurrency = 4
+	// DefaultUploadMemoryLimit — общий для всех загрузок бюджет памяти под буферы частей.
+	DefaultUploadMemoryLimit int64 = 256 << 20
+)
+
+// partSource описывает, как резать входной поток на части.
+type partSource struct {
+	r io.Reader
+	// total — известное число частей; 0 означает поток неизвестной длины.
+	total int
+	// size — известная длина потока (используется только при total > 0).
+	size int64
+	// partSize — размер каждой части, кроме, возможно, последней.
+	partSize int64
+	// storages — заранее выбранные реплики по индексу части; для потока выбираются по одной части.
+	storages [][]string
+}
+
+// uploadParts читает поток последовательно, буферизует части и отправляет до UploadConcurrency
+// частей на стораджи параллельно (репликами либо шардами erasure coding). Буфер части держится в памяти в пределах общего бюджета,
+// остальное сбрасывается во временный файл.
+func (s *Files) uploadParts(ctx context.Context, fileID string, src partSource) (map[int]models.Part, error) {
+	g, gctx := errgroup.WithContext(ctx)
+	slots := make(chan struct{}, s.uploadConcurrency())
+
+	var (
+		mu      sync.Mutex
+		parts   = make(map[int]models.Part)
+		readErr error
+	)
+
+	remaining := src.size
+	for idx := 0; src.total == 0 || idx < src.total; idx++ {
+		// Свободный слот ограничивает и число параллельных PUT, и число буферов одновременно.
+		select {
+		case slots <- struct{}{}:
+		case <-gctx.Done():
+		}
+		if gctx.Err() != nil {
+			break
+		}
+
+		want := src.partSize
+		if src.total > 0 {
+			want = min(src.partSize, remaining)
+		}
+
+		buf, release := s.newPartBuffer(want)
+		n, err := buf.fill(src.r, want)
+		last := errors.Is(err, io.EOF)
+		switch {
+		case err != nil && !last:
+			readErr = fmt.Errorf("read part %d: %w", idx, err)
+		case src.total > 0 && n != want:
+			readErr = fmt.Errorf("unexpected part length: want %d, got %d", want, n)
+		}
+
+		// Поток закончился ровно на границе части: пустую хвостовую часть не храним.
+		emptyTail := src.total == 0 && n == 0 && idx > 0
+		if readErr != nil || emptyTail {
+			_ = buf.Close()
+			release()
+			<-slots
+			break
+		}
+
+		var nodes []string
+		if src.total > 0 {
+			nodes = src.storages[idx]
+		} else {
+			width, minimum := s.placement(s.erasureLayout())
+			allocated, err := s.Router.AllocateReplicas(gctx, 1, width, minimum)
+			if err != nil {
+				_ = buf.Close()
+				release()
+				<-slots
+				readErr = err
+				break
+			}
+			nodes = allocated[0]
+		}
+
+		part := models.Part{
+			Index:  idx,
+			Size:   buf.Size(),
+			Sha256: buf.Sha256(),
+		}
+		g.Go(func() error {
+			defer func() {
+				_ = buf.Close()
+				release()
+				<-slots
+			}()
+
+			if layout := s.erasureLayout(); layout != nil {
+				if err := s.putShards(gctx, fileID, *layout, &part, buf, src.total, nodes); err != nil {
+					return err
+				}
+			} else {
+				stored, err := s.putReplicas(gctx, fileID, part, buf, src.total, nodes)
+				if err != nil {
+					return err
+				}
+				part.Storage = stored[0]
+				part.Storages = stored
+			}
+
+			mu.Lock()
+			parts[part.Index] = part
+			mu.Unlock()
+			return nil
+		})
+
+		remaining -= n
+		if src.total == 0 && last {
+			break
+		}
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return parts, nil
+}
+
+// putReplicas пишет часть на все выбранные узлы параллельно и возвращает те, где запись удалась.
+// Ошибка возвращается, только если успешных копий меньше кворума записи; узлы с неудачной записью
+// ставятся в очередь отложенных удалений, чтобы не оставлять на них обрывки.
+func (s *Files) putReplicas(ctx context.Context, fileID string, part models.Part, buf *partBuffer, total int, nodes []string) ([]string, error) {
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.StorageCli.PutPart(ctx, node, storageclient.PutPartRequest{
+				FileID:     fileID,
+				Index:      part.Index,
+				Reader:     buf.Reader(),
+				Size:       part.Size,
+				Sha256:     part.Sha256,
+				TotalParts: total,
+			})
+		}()
+	}
+	wg.Wait()
+
+	var (
+		stored  = make([]string, 0, len(nodes))
+		failed  []error
+		cleanup []models.PendingDeletion
+	)
+	for i, node := range nodes {
+		if errs[i] == nil {
+			stored = append(stored, node)
+			continue
+		}
+		failed = append(failed, fmt.Errorf("put part %d to %s: %w", part.Index, node, errs[i]))
+		cleanup = append(cleanup, models.PendingDeletion{
+			FileID:    fileID,
+			Index:     part.Index,
+			Storage:   node,
+			LastError: errs[i].Error(),
+		})
+	}
+
+	_ = s.queueDeletions(ctx, cleanup)
+	if len(stored) < s.writeQuorum() {
+		return nil, fmt.Errorf("part %d: %d of %d replicas written, quorum %d: %w",
+			part.Index, len(stored), len(nodes), s.writeQuorum(), errors.Join(failed...))
+	}
+
+	return stored, nil
+}
+
+// finalizeStorages сообщает каждому стораджу с частями файла итоговое число частей и завершает
+// на нём загрузку: узел хранит лишь свою долю частей, и без этого GC счёл бы файл брошенным.
+func (s *Files) finalizeStorages(ctx context.Context, file models.File) error {
+	storages := make(map[string]struct{}, len(file.Parts))
+	for _, p := range file.Parts {
+		for _, pl := range p.Placements() {
+			storages[pl.Storage] = struct{}{}
+		}
+	}
+
+	total := storageTotal(file.Erasure, file.TotalParts)
+	for storage := range storages {
+		if err := s.StorageCli.FinalizeFile(ctx, storage, file.ID, total); err != nil {
+			return fmt.Errorf("finalize file %s on %s: %w", file.ID, storage, err)
+		}
+	}
+
+	return nil
+}
+
+// commitFinalized завершает файл на стораджах и сохраняет его метаданные. Завершённые части GC стораджа
+// не удаляет, поэтому при сбое любого из шагов все части файла ставятся в очередь удаления.
+func (s *Files) commitFinalized(ctx context.Context, file models.File) error {
+	err := s.finalizeStorages(ctx, file)
+	if err == nil {
+		err = s.commit(ctx, file)
+	}
+	if err != nil {
+		var pending []models.PendingDeletion
+		for _, p := range file.Parts {
+			pending = append(pending, pendingFor(file.ID, p.Placements())...)
+		}
+		_ = s.queueDeletions(ctx, pending)
+	}
+
+	return err
+}
+
+// newPartBuffer создаёт буфер под часть размера want, резервируя под него память из общего бюджета.
+// Если бюджет исчерпан, часть целиком уходит во временный файл. release возвращает резерв.
+func (s *Files) newPartBuffer(want int64) (*partBuffer, func()) {
+	memLimit := min(want, s.uploadMemoryLimit()/int64(s.uploadConcurrency()))
+	if memLimit <= 0 || !s.memBudget.TryAcquire(memLimit) {
+		return newPartBuffer(0, s.SpoolDir), func() {}
+	}
+
+	var once sync.Once
+	return newPartBuffer(memLimit, s.SpoolDir), func() {
+		once.Do(func() { s.memBudget.Release(memLimit) })
+	}
+}
+
+func (s *Files) uploadConcurrency() int {
+	if s.UploadConcurrency <= 0 {
+		return DefaultUploadConcurrency
+	}
+	return s.UploadConcurrency
+}
+
+func (s *Files) replicationFactor() int {
+	if s.ReplicationFactor <= 0 {
+		return 1
+	}
+	return s.ReplicationFactor
+}
+
+// writeQuorum — сколько копий части должно записаться, чтобы загрузка считалась успешной
+// (по умолчанию большинство от фактора репликации).
+func (s *Files) writeQuorum() int {
+	factor := s.replicationFactor()
+	if s.WriteQuorum <= 0 {
+		return factor/2 + 1
+	}
+	return min(s.WriteQuorum, factor)
+}
+
+func (s *Files) uploadMemoryLimit() int64 {
+	if s.UploadMemoryLimit <= 0 {
+		return DefaultUploadMemoryLimit
+	}
+	return s.UploadMemoryLimit
+}
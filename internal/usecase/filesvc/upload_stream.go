@@ -0,0 +1,55 @@
+package filesvc
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir_venger/s3_lite/internal/models"
+)
+
+// DefaultStreamPartSize — размер части для загрузок без Content-Length.
+const DefaultStreamPartSize int64 = 64 << 20
+
+// uploadStreaming загружает поток неизвестной длины: тело режется на части по StreamPartSize,
+// сторадж для каждой части выбирается по мере чтения, а число частей и размер фиксируются в конце.
+func (s *Files) uploadStreaming(ctx context.Context, r io.Reader, target models.File) (models.UploadResult, error) {
+	partSize := s.StreamPartSize
+	if partSize <= 0 {
+		partSize = DefaultStreamPartSize
+	}
+	layout := s.erasureLayout()
+	if layout != nil {
+		partSize = s.erasureStripeSize()
+	}
+
+	file := models.File{
+		ID:        uuid.NewString(),
+		Name:      target.Name,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		Erasure:   layout,
+		Bucket:    target.Bucket,
+		Key:       target.Key,
+	}
+
+	parts, err := s.uploadParts(ctx, file.ID, partSource{
+		r:        r,
+		partSize: partSize,
+	})
+	if err != nil {
+		return models.UploadResult{}, err
+	}
+
+	file.Parts = parts
+	file.TotalParts = len(parts)
+	for _, p := range parts {
+		file.Size += p.Size
+	}
+
+	if err := s.commitFinalized(ctx, file); err != nil {
+		return models.UploadResult{}, err
+	}
+
+	return models.UploadResult{FileID: file.ID, Size: file.Size, Parts: file.TotalParts}, nil
+}
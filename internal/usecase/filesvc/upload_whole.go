@@ -2,26 +2,34 @@ package filesvc
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
 	"io"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sir_venger/s3_lite/internal/models"
-	"github.com/sir_venger/s3_lite/pkg/storageclient"
 )
 
 // UploadWhole читает поток постранично, делит на части и распределяет их по стораджам.
+// Отрицательный size означает, что длина заранее неизвестна (chunked transfer encoding).
 func (s *Files) UploadWhole(ctx context.Context, r io.Reader, size int64, name string) (models.UploadResult, error) {
+	return s.upload(ctx, r, size, models.File{Name: strings.TrimSpace(name)})
+}
+
+// upload записывает тело как новый файл с именем (и адресом объекта) из target.
+func (s *Files) upload(ctx context.Context, r io.Reader, size int64, target models.File) (models.UploadResult, error) {
 	if size < 0 {
-		return models.UploadResult{}, fmt.Errorf("content length is required")
+		return s.uploadStreaming(ctx, r, target)
 	}
 
 	plan := determineParts(size, s.Parts)
-	storages, err := s.Router.Allocate(ctx, plan.Total)
+	layout := s.erasureLayout()
+	if layout != nil {
+		plan = stripeParts(size, s.erasureStripeSize())
+	}
+	width, minimum := s.placement(layout)
+	storages, err := s.Router.AllocateReplicas(ctx, plan.Total, width, minimum)
 	if err != nil {
 		return models.UploadResult{}, err
 	}
@@ -29,61 +37,47 @@ func (s *Files) UploadWhole(ctx context.Context, r io.Reader, size int64, name s
 	fileID := uuid.NewString()
 	file := models.File{
 		ID:         fileID,
-		Name:       strings.TrimSpace(name),
+		Name:       target.Name,
 		Size:       size,
 		TotalParts: plan.Total,
-		Parts:      make(map[int]models.Part, plan.Total),
-	}
-
-	remaining := size
-	for idx := 0; idx < plan.Total; idx++ {
-		if ctx.Err() != nil {
-			return models.UploadResult{}, ctx.Err()
-		}
-
-		partSize := min(plan.Size, remaining)
-		limited := &io.LimitedReader{R: r, N: partSize}
-		hasher := sha256.New()
-		reader := io.TeeReader(limited, hasher)
-		req := storageclient.PutPartRequest{
-			FileID:     fileID,
-			Index:      idx,
-			Reader:     reader,
-			Size:       partSize,
-			TotalParts: plan.Total,
-		}
-
-		storage := storages[idx]
-		if err = s.StorageCli.PutPart(ctx, storage, req); err != nil {
-			return models.UploadResult{}, err
-		}
-
-		written := partSize - limited.N
-		if written != partSize {
-			return models.UploadResult{}, fmt.Errorf("unexpected part length: want %d, got %d", partSize, written)
-		}
-		sha := hex.EncodeToString(hasher.Sum(nil))
-		file.Parts[idx] = models.Part{
-			Index:   idx,
-			Size:    written,
-			Sha256:  sha,
-			Storage: storage,
-		}
-
-		remaining -= written
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+		Erasure:    layout,
+		Bucket:     target.Bucket,
+		Key:        target.Key,
 	}
 
-	if remaining != 0 {
-		return models.UploadResult{}, fmt.Errorf("incomplete upload: %d bytes left", remaining)
+	parts, err := s.uploadParts(ctx, fileID, partSource{
+		r:        r,
+		total:    plan.Total,
+		size:     size,
+		partSize: plan.Size,
+		storages: storages,
+	})
+	if err != nil {
+		return models.UploadResult{}, err
 	}
+	file.Parts = parts
 
-	if err = s.MetaStorage.Save(ctx, file); err != nil {
+	if err = s.commitFinalized(ctx, file); err != nil {
 		return models.UploadResult{}, err
 	}
 
 	return models.UploadResult{FileID: fileID, Size: size, Parts: plan.Total}, nil
 }
 
+// stripeParts режет файл на полосы фиксированного размера для erasure coding:
+// полоса целиком держится в памяти при кодировании и восстановлении.
+func stripeParts(length, stripe int64) models.ChunkPlan {
+	if length <= 0 {
+		return models.ChunkPlan{Total: 1, Size: 0}
+	}
+
+	return models.ChunkPlan{
+		Total: int((length + stripe - 1) / stripe),
+		Size:  stripe,
+	}
+}
+
 // determineParts вычисляет оптимальное число частей и размер каждой.
 func determineParts(length int64, desired int) models.ChunkPlan {
 	if desired <= 0 {
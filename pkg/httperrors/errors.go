@@ -9,19 +9,44 @@ import (
 )
 
 func Write(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), Status(err))
+}
+
+// Status возвращает HTTP-статус для ошибки (например, для HEAD-ответов без тела).
+func Status(err error) int {
+	var maxBytes *http.MaxBytesError
 	switch {
-	case errors.Is(err, models.ErrNotFound):
-		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrNotFound), errors.Is(err, models.ErrUploadNotFound), errors.Is(err, models.ErrBucketNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrBucketExists), errors.Is(err, models.ErrBucketNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrKeyNotFound), errors.Is(err, models.ErrStorageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
 	case errors.Is(err, models.ErrIncomplete):
-		http.Error(w, err.Error(), http.StatusConflict)
+		return http.StatusConflict
 	case errors.Is(err, models.ErrNoStorage):
-		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return http.StatusServiceUnavailable
+	case errors.Is(err, models.ErrBadCursor), errors.Is(err, models.ErrInvalidPart), errors.Is(err, models.ErrInvalidName),
+		errors.Is(err, models.ErrInvalidStorage), errors.Is(err, models.ErrInvalidPermission):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrTooLarge), errors.As(err, &maxBytes):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, models.ErrOffset):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrUploadLocked):
+		return http.StatusLocked
+	case errors.Is(err, models.ErrCorrupted):
+		// Сторадж отдал данные, не совпавшие с контрольной суммой: ошибка вышестоящего узла.
+		return http.StatusBadGateway
 	default:
 		if containsAny(err.Error(), "must be > 0", "part verification failed", "size mismatch", "part index out of range", "missing part") {
-			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
-			return
+			return http.StatusUnprocessableEntity
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return http.StatusInternalServerError
 	}
 }
 
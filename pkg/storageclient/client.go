@@ -24,6 +24,12 @@ type Client interface {
 	PutPart(ctx context.Context, baseURL string, req PutPartRequest) error
 	// GetPart Достать часть файла в хранилище
 	GetPart(ctx context.Context, baseURL, fileID string, index int) (io.ReadCloser, error)
+	// GetPartRange Достать length байт части, начиная со смещения offset
+	GetPartRange(ctx context.Context, baseURL, fileID string, index int, offset, length int64) (io.ReadCloser, error)
+	// FinalizeFile Зафиксировать итоговое число частей файла, загруженного без известной длины
+	FinalizeFile(ctx context.Context, baseURL, fileID string, totalParts int) error
+	// DeletePart Удалить часть файла из хранилища. Отсутствие части не считается ошибкой
+	DeletePart(ctx context.Context, baseURL, fileID string, index int) error
 }
 
 type httpClient struct {
@@ -45,7 +51,8 @@ func (h *httpClient) PutPart(ctx context.Context, baseURL string, req PutPartReq
 		return err
 	}
 
-	httpReq.Header.Set("Content-Length", strconv.FormatInt(req.Size, 10))
+	// Явная длина нужна, чтобы тело ушло с Content-Length, а не chunked, даже для составных Reader.
+	httpReq.ContentLength = req.Size
 	if req.Sha256 != "" {
 		httpReq.Header.Set(storageproto.HeaderChecksum, req.Sha256)
 	}
@@ -74,7 +81,6 @@ func (h *httpClient) GetPart(ctx context.Context, baseURL, fileID string, index
 
 	resp, err := h.c.Do(req)
 	if err != nil {
-		resp.Body.Close()
 		return nil, err
 	}
 
@@ -85,3 +91,109 @@ func (h *httpClient) GetPart(ctx context.Context, baseURL, fileID string, index
 
 	return resp.Body, nil
 }
+
+// FinalizeFile сообщает storage итоговое число частей файла.
+func (h *httpClient) FinalizeFile(ctx context.Context, baseURL, fileID string, totalParts int) error {
+	u := fmt.Sprintf(storageproto.FinalizePathFormat, baseURL, fileID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(storageproto.HeaderTotalParts, strconv.Itoa(totalParts))
+
+	resp, err := h.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("storage finalize failed: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// GetPartRange скачивает диапазон [offset, offset+length) части.
+// Если узел не поддерживает Range и отдаёт часть целиком, лишние байты отбрасываются на клиенте.
+func (h *httpClient) GetPartRange(ctx context.Context, baseURL, fileID string, index int, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("invalid part range: offset %d, length %d", offset, length)
+	}
+
+	u := fmt.Sprintf(storageproto.PartsPathFormat, baseURL, fileID, index)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := h.c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if err = checkContentRange(resp.Header.Get("Content-Range"), offset, length); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return limitedBody(resp.Body, length), nil
+	case http.StatusOK:
+		if _, err = io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("skip to part offset %d: %w", offset, err)
+		}
+		return limitedBody(resp.Body, length), nil
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage GET range failed: %s", resp.Status)
+	}
+}
+
+// checkContentRange убеждается, что узел вернул именно запрошенный диапазон.
+func checkContentRange(header string, offset, length int64) error {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(header, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return fmt.Errorf("storage GET range: invalid Content-Range %q", header)
+	}
+	if start != offset || end-start+1 != length {
+		return fmt.Errorf("storage GET range: got bytes %d-%d, want %d-%d", start, end, offset, offset+length-1)
+	}
+
+	return nil
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func limitedBody(body io.ReadCloser, n int64) io.ReadCloser {
+	return limitedReadCloser{Reader: io.LimitReader(body, n), Closer: body}
+}
+
+// DeletePart удаляет часть файла на указанном storage.
+func (h *httpClient) DeletePart(ctx context.Context, baseURL, fileID string, index int) error {
+	u := fmt.Sprintf(storageproto.PartsPathFormat, baseURL, fileID, index)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("storage DELETE failed: %s", resp.Status)
+	}
+
+	return nil
+}
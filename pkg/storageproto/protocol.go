@@ -1,8 +1,9 @@
 package storageproto
 
 const (
-	PartsPathFormat  = "%s/parts/%s/%d"
-	HeaderChecksum   = "X-Checksum-Sha256"
-	HeaderTotalParts = "X-Total-Parts"
-	HeaderPartSize   = "X-Size"
+	PartsPathFormat    = "%s/parts/%s/%d"
+	FinalizePathFormat = "%s/parts/%s/finalize"
+	HeaderChecksum     = "X-Checksum-Sha256"
+	HeaderTotalParts   = "X-Total-Parts"
+	HeaderPartSize     = "X-Size"
 )